@@ -4,11 +4,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/Masterminds/sprig/v3"
 	"github.com/anaskhan96/soup"
 	"github.com/charmbracelet/huh"
 	ll "github.com/gwennlbh/label-logger-go"
@@ -86,26 +92,65 @@ func DetectStartDate(workingDirectory string) (time.Time, error) {
 	return time.Now(), fmt.Errorf("no way to autodetect start date of %s", workingDirectory)
 }
 
+func validateDate(raw string) error {
+	_, err := ParseDateOrRange(raw)
+	return err
+}
+
+// decodeMetadataItem sets the metadata value described by item, written as key=value.
+// Keys are matched like front matter keys, regardless of case, spaces, dashes and underscores. Values of list keys (tags, made with, aliases and collections) are appended to.
+// Unknown keys end up in the additional metadata, with their value decoded as YAML, so that numbers, booleans and lists keep their type.
 func decodeMetadataItem(item string, metadata *WorkMetadata) error {
-	parts := strings.SplitN(item, ":", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid metadata item: %s", item)
+	key, value, found := strings.Cut(item, "=")
+	if !found {
+		return fmt.Errorf("invalid metadata item %q, use key=value", item)
 	}
-	key := strings.TrimSpace(parts[0])
-	value := strings.TrimSpace(parts[1])
-	switch key {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	switch normalizeFrontMatterKey(key) {
 	case "started":
-		metadata.Started = value
+		date, err := ParseDateOrRange(value)
+		if err != nil {
+			return err
+		}
+		metadata.Started = date
 	case "finished":
-		metadata.Finished = value
-	case "tag":
-	case "tags":
+		date, err := ParseDateOrRange(value)
+		if err != nil {
+			return err
+		}
+		metadata.Finished = date
+	case "tag", "tags":
 		metadata.Tags = append(metadata.Tags, value)
-	case "madewith":
-	case "using":
+	case "madewith", "using", "technology", "technologies":
 		metadata.MadeWith = append(metadata.MadeWith, value)
+	case "alias", "aliases":
+		metadata.Aliases = append(metadata.Aliases, value)
+	case "collection", "collections":
+		metadata.Collections = append(metadata.Collections, value)
+	case "wip", "private":
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, not %q", key, value)
+		}
+		if normalizeFrontMatterKey(key) == "wip" {
+			metadata.WIP = flag
+		} else {
+			metadata.Private = flag
+		}
+	case "thumbnail":
+		metadata.Thumbnail = FilePathInsidePortfolioFolder(value)
+	case "pagebackground":
+		metadata.PageBackground = value
 	default:
-		return fmt.Errorf("unknown metadata key: %s", key)
+		var decoded any
+		if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+			decoded = value
+		}
+		if metadata.AdditionalMetadata == nil {
+			metadata.AdditionalMetadata = make(map[string]any)
+		}
+		metadata.AdditionalMetadata[key] = decoded
 	}
 	return nil
 }
@@ -130,15 +175,208 @@ func fromReadme(readmePath string) (title string, firstParagraph string, err err
 	return
 }
 
-func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []string, forceOverwrite bool) (string, error) {
-	output := ""
-	outputPath := filepath.Join(ctx.PathToWorkFolder(workId), ctx.Config.ScatteredModeFolder, "description.md")
+// readmeImagePattern matches images of a markdown file, capturing their source.
+var readmeImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)`)
+
+// Maximum number of screenshots that are added as media blocks by CreateDescriptionFile.
+const maxScreenshots = 6
+
+// findScreenshots returns paths, relative to the work's folder, of images that show the work: images of the README that are in the work's folder, then images of its docs and screenshots folders.
+func (ctx *RunContext) findScreenshots(workId string, readme string) []string {
+	workFolder := filepath.Join(ctx.DatabaseDirectory, workId)
+	screenshots := make([]string, 0)
+	add := func(path string) {
+		path = filepath.ToSlash(filepath.Clean(path))
+		if len(screenshots) >= maxScreenshots || slices.Contains(screenshots, path) || strings.HasPrefix(path, "../") {
+			return
+		}
+		if strings.Contains(path, " ") || !isImageFile(path) || !fileExists(filepath.Join(workFolder, path)) {
+			ll.Debug("Not using %s as a screenshot of %s", path, workId)
+			return
+		}
+		screenshots = append(screenshots, path)
+	}
+
+	for _, groups := range readmeImagePattern.FindAllStringSubmatch(readme, -1) {
+		if !isValidURL(groups[1]) {
+			add(groups[1])
+		}
+	}
+
+	scan, err := ctx.scanWork(workId)
+	if err != nil {
+		ll.WarnDisplay("while looking for screenshots of %s", err, workId)
+		return screenshots
+	}
+	for _, entry := range scan.entries {
+		folder, _, _ := strings.Cut(entry.path, "/")
+		if !entry.isDir && (folder == "docs" || folder == "screenshots") {
+			add(entry.path)
+		}
+	}
+	return screenshots
+}
+
+func isImageFile(path string) bool {
+	return slices.Contains([]string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif"}, strings.ToLower(filepath.Ext(path)))
+}
+
+// workManifest returns the manifest at the root of the work's folder, or else the first one found in its subfolders.
+func (ctx *RunContext) workManifest(workId string) (Manifest, bool) {
+	scan, err := ctx.scanWork(workId)
+	if err != nil || len(scan.manifests) == 0 {
+		return Manifest{}, false
+	}
+	for _, manifest := range scan.manifests {
+		if !strings.Contains(manifest.Path, "/") {
+			return manifest, true
+		}
+	}
+	return scan.manifests[0], true
+}
+
+// descriptionLanguages returns the languages that the description files of the database are translated to, sorted.
+func (ctx *RunContext) descriptionLanguages() []string {
+	languages := make([]string, 0)
+	workDirectories, err := ctx.ComputeProgressTotal()
+	if err != nil {
+		return languages
+	}
+	for _, dirEntry := range workDirectories {
+		raw, err := readFile(ctx.DescriptionFilename(ctx.DatabaseDirectory, dirEntry.Name()))
+		if err != nil {
+			continue
+		}
+		_, markdownRaw, _ := splitFrontMatter(raw)
+		_, perLanguage := SplitOnLanguageMarkers(markdownRaw)
+		for language := range perLanguage {
+			language = strings.TrimSpace(language)
+			if !slices.Contains(languages, language) {
+				languages = append(languages, language)
+			}
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// AddOptions holds the values given to CreateDescriptionFile. Values that are left empty are asked for interactively, or detected when NoInteractive is set.
+type AddOptions struct {
+	Title   string
+	Summary string
+	// Summaries in other languages. Maps language codes to summaries.
+	LocalizedSummaries map[string]string
+	// Tags and technologies to use instead of the detected ones. Nil means that they are detected.
+	Tags         []string
+	Technologies []string
+	Started      string
+	Finished     string
+	// Nil means that it is asked for, or that the work is considered finished when NoInteractive is set.
+	WIP *bool
+	// Additional metadata, written as key=value. See decodeMetadataItem.
+	Metadata []string
+	// Never ask anything: use detected values or defaults for values that are not set.
+	NoInteractive bool
+	// Overwrite the description file if it exists. Without it, the user is asked, or an error is returned when NoInteractive is set.
+	Overwrite bool
+	// Name of a template in the add.templates directory, or path to a template file. See DescriptionTemplateData.
+	Template string
+}
+
+// DefaultDescriptionTemplate is the template used by CreateDescriptionFile when no template is given. See DescriptionTemplateData.
+const DefaultDescriptionTemplate = `---
+{{ .FrontMatter }}---
+
+# {{ .Title }}
+
+{{ with .Summary }}{{ . }}
+
+{{ end }}{{ range .Media }}![]({{ . }})
+
+{{ end }}{{ with .Homepage }}[Website]({{ . }})
+
+{{ end }}{{ with .SourceCodeURL }}[Source code]({{ . }})
+
+{{ end }}{{ range $language, $summary := .LocalizedSummaries }}:: {{ $language }}
+
+{{ $summary }}
+
+{{ end }}`
+
+// DescriptionTemplateData is the data available to description templates, which are rendered with text/template (https://pkg.go.dev/text/template) and the sprig functions (https://masterminds.github.io/sprig/).
+type DescriptionTemplateData struct {
+	ID      string
+	Title   string
+	Summary string
+	// Summaries in other languages, to put after language markers. Maps language codes to summaries.
+	LocalizedSummaries map[string]string
+	// Metadata of the work, as YAML, to put between --- lines.
+	FrontMatter string
+	Metadata    WorkMetadata
+	// Paths to screenshots of the work, relative to the description file, to use as media blocks.
+	Media []string
+	// URL of the git remote of the work's folder, if any.
+	SourceCodeURL string
+	// URL of the work's website, from its manifest file (see Manifest), if any.
+	Homepage string
+}
+
+// DescriptionTemplate returns the contents of the template with the given name: a path to a template file, or the name of a file (without its .md extension) in the add.templates directory of the configuration.
+// An empty name gives DefaultDescriptionTemplate.
+func (ctx *RunContext) DescriptionTemplate(name string) (string, error) {
+	if name == "" {
+		return DefaultDescriptionTemplate, nil
+	}
+	if fileExists(name) {
+		return readFile(name)
+	}
+	if ctx.Config.Add.Templates == "" {
+		return "", fmt.Errorf("template %s does not exist, and no templates directory is set with add.templates in the configuration", name)
+	}
+	path := filepath.Join(ctx.Config.Add.Templates, name+".md")
+	if !fileExists(path) {
+		available := make([]string, 0)
+		entries, _ := os.ReadDir(ctx.Config.Add.Templates)
+		for _, entry := range entries {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ".md" {
+				available = append(available, filepathBaseNoExt(entry.Name()))
+			}
+		}
+		return "", fmt.Errorf("no template named %s in %s, available templates are %s", name, ctx.Config.Add.Templates, strings.Join(available, ", "))
+	}
+	return readFile(path)
+}
+
+// RenderDescriptionTemplate renders the given template with data. See DescriptionTemplateData.
+func RenderDescriptionTemplate(contents string, data DescriptionTemplateData) (string, error) {
+	tmpl, err := template.New("description").Funcs(sprig.TxtFuncMap()).Parse(contents)
+	if err != nil {
+		return "", fmt.Errorf("while parsing template: %w", err)
+	}
+	var output strings.Builder
+	err = tmpl.Execute(&output, data)
+	if err != nil {
+		return "", fmt.Errorf("while rendering template: %w", err)
+	}
+	// Optional sections at the end of templates leave empty lines behind
+	return strings.TrimRight(output.String(), "\n") + "\n", nil
+}
+
+// CreateDescriptionFile creates the description file of the given work, with values from options, values detected from the work's folder, and answers to questions for the rest, unless options.NoInteractive is set.
+// Returns the path to the created file.
+func (ctx *RunContext) CreateDescriptionFile(workId string, options AddOptions) (string, error) {
+	outputPath := ctx.DescriptionFilename(ctx.DatabaseDirectory, workId)
 
-	if _, err := os.Stat(ctx.PathToWorkFolder(workId)); os.IsNotExist(err) {
-		return outputPath, fmt.Errorf("folder for given work %s (%s) does not exist", workId, ctx.PathToWorkFolder(workId))
+	// In scattered mode, the .ortfo folder does not need to exist yet
+	workFolder := filepath.Join(ctx.DatabaseDirectory, workId)
+	if _, err := os.Stat(workFolder); os.IsNotExist(err) {
+		return outputPath, fmt.Errorf("folder for given work %s (%s) does not exist", workId, workFolder)
 	}
 
-	if _, err := os.Stat(outputPath); err == nil && !forceOverwrite {
+	if _, err := os.Stat(outputPath); err == nil && !options.Overwrite {
+		if options.NoInteractive {
+			return outputPath, fmt.Errorf("%s already exists, use --overwrite to replace it", outputPath)
+		}
 		confirmOverwrite := false
 		huh.NewForm(huh.NewGroup(
 			huh.NewConfirm().
@@ -150,6 +388,12 @@ func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []stri
 		}
 	}
 
+	// Fail early, before asking anything
+	descriptionTemplate, err := ctx.DescriptionTemplate(options.Template)
+	if err != nil {
+		return outputPath, err
+	}
+
 	allTags, err := ctx.LoadTagsRepository()
 	if err != nil {
 		return outputPath, fmt.Errorf("while reading all available tags: %w", err)
@@ -173,7 +417,17 @@ func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []stri
 	defaultProjectTitle := titleCase(workId)
 	defaultSummary := ""
 
-	readmePath := filepath.Join(ctx.PathToWorkFolder(workId), "README.md")
+	manifest, hasManifest := ctx.workManifest(workId)
+	if hasManifest {
+		ll.Log("Detected", "cyan", "manifest file [bold][blue]%s[reset]", manifest.Path)
+		if manifest.Name != "" {
+			defaultProjectTitle = titleCase(path.Base(manifest.Name))
+		}
+		defaultSummary = manifest.Description
+	}
+
+	readme := ""
+	readmePath := filepath.Join(workFolder, "README.md")
 	if fileExists(readmePath) {
 		readmeTitle, readmeBody, err := fromReadme(readmePath)
 		if err != nil {
@@ -182,17 +436,39 @@ func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []stri
 			if readmeTitle != "" {
 				defaultProjectTitle = readmeTitle
 			}
-			defaultSummary = readmeBody
+			if readmeBody != "" {
+				defaultSummary = readmeBody
+			}
+		}
+		readme, _ = readFile(readmePath)
+	}
+
+	screenshots := ctx.findScreenshots(workId, readme)
+	if len(screenshots) > 0 {
+		ll.Log("Detected", "cyan", "screenshots %s", ll.List(screenshots, "[bold][blue]%s[reset]", ", "))
+	}
+
+	languages := ctx.descriptionLanguages()
+	localizedSummaries := make(map[string]*string, len(languages))
+	for _, language := range languages {
+		summary := options.LocalizedSummaries[language]
+		localizedSummaries[language] = &summary
+	}
+	for language, summary := range options.LocalizedSummaries {
+		if _, ok := localizedSummaries[language]; !ok {
+			localizedSummaries[language] = &summary
 		}
 	}
 
-	detectedStartDate, err := DetectStartDate(ctx.PathToWorkFolder(workId))
 	defaultStartedAt := ""
-	if err != nil {
-		ll.WarnDisplay("while detecting start date of %s", err, workId)
-	} else {
-		defaultStartedAt = detectedStartDate.Format("2006-01-02")
-		ll.Log("Detected", "cyan", "start date to be [bold][blue]%s[reset]", defaultStartedAt)
+	if options.Started == "" {
+		detectedStartDate, err := DetectStartDate(workFolder)
+		if err != nil {
+			ll.WarnDisplay("while detecting start date of %s", err, workId)
+		} else {
+			defaultStartedAt = detectedStartDate.Format("2006-01-02")
+			ll.Log("Detected", "cyan", "start date to be [bold][blue]%s[reset]", defaultStartedAt)
+		}
 	}
 
 	startedAtPlaceholder := "YYYY-MM-DD"
@@ -206,91 +482,158 @@ func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []stri
 		MadeWith: []string{},
 	}
 
-	autodetectedTechs, err := ctx.DetectTechnologies(workId)
-	if err != nil {
+	technologies := make([]Technology, 0)
+	if options.Technologies != nil {
+		metadata.MadeWith = append(metadata.MadeWith, options.Technologies...)
+		for _, name := range options.Technologies {
+			if tech, ok := ctx.FindTechnology(name); ok {
+				technologies = append(technologies, tech)
+			}
+		}
+	} else if autodetectedTechs, err := ctx.DetectTechnologies(workId); err != nil {
 		ll.Warn(ll.FormatErrors(fmt.Errorf("while autodetecting technologies for %s: %w", workId, err)))
 	} else {
 		displayTags := make([]string, 0, len(autodetectedTechs))
 		for _, tech := range autodetectedTechs {
 			metadata.MadeWith = append(metadata.MadeWith, tech.Slug)
-			displayTags = append(displayTags, tech.String())
+			technologies = append(technologies, tech.Technology)
+			displayTags = append(displayTags, fmt.Sprintf("[bold][blue]%s[reset] [dim](%s)[reset]", tech, tech.Detection))
 		}
 		if len(metadata.MadeWith) > 0 {
-			ll.Log("Detected", "cyan", "technologies to be %s", ll.List(displayTags, "[bold][blue]%s[reset]", ", "))
+			ll.Log("Detected", "cyan", "technologies to be %s", ll.List(displayTags, "%s", ", "))
 		}
 	}
 
-	autodetectedTags, err := ctx.DetectTags(workId, autodetectedTechs)
-	if err != nil {
+	if options.Tags != nil {
+		metadata.Tags = append(metadata.Tags, options.Tags...)
+	} else if autodetectedTags, err := ctx.DetectTags(workId, technologies); err != nil {
 		ll.WarnDisplay("while autodetecting tags for %s", err, workId)
 	} else {
+		displayTags := make([]string, 0, len(autodetectedTags))
 		for _, tag := range autodetectedTags {
 			metadata.Tags = append(metadata.Tags, tag.String())
+			displayTags = append(displayTags, fmt.Sprintf("[bold][blue]%s[reset] [dim](%s)[reset]", tag, tag.Detection))
 		}
 		if len(metadata.Tags) > 0 {
-			ll.Log("Detected", "cyan", "tags to be %s", ll.List(metadata.Tags, "[bold][blue]%s[reset]", ", "))
-		}
-	}
-
-	var projectTitle string
-	var summary string
-
-	err = huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().Title("Title").Placeholder(defaultProjectTitle).Value(&projectTitle),
-
-			huh.NewText().Title("Summary").Description("A short description of the work").Placeholder(defaultSummary).Value(&summary),
-		),
-		huh.NewGroup(
-			huh.NewMultiSelect[string]().
-				Title("Technologies").
-				Description("What was the work made with?").
-				Filterable(true).
-				Value(&metadata.MadeWith).
-				Options(allTechsOptions...).
-				Validate(func(s []string) error {
-					ll.Debug("Selected %v", s)
-					return nil
-				}).
-				Height(2+6),
-
-			huh.NewMultiSelect[string]().
-				Title("Tags").
-				Description("Categorize your work").
-				Filterable(true).
-				Value(&metadata.Tags).
-				Options(allTagsOptions...).
-				Height(2+6),
-		),
-		huh.NewGroup(
-			huh.NewInput().Description("When did you start working on this?").Placeholder(startedAtPlaceholder).Value(&metadata.Started),
-
-			huh.NewConfirm().Title("Work in progress").Description("What's the status?").Value(&metadata.WIP).Affirmative("WIP").Negative("Finished"),
-		),
-	).Run()
+			ll.Log("Detected", "cyan", "tags to be %s", ll.List(displayTags, "%s", ", "))
+		}
+	}
 
-	if err != nil {
-		return outputPath, fmt.Errorf("while getting your answers: %w", err)
+	projectTitle := options.Title
+	summary := options.Summary
+	startedAt := options.Started
+	finishedAt := options.Finished
+	if options.WIP != nil {
+		metadata.WIP = *options.WIP
+	} else if finishedAt != "" {
+		metadata.WIP = false
+	}
+
+	// Only ask for what was not given
+	if !options.NoInteractive {
+		groups := make([]*huh.Group, 0)
+		if options.Title == "" || (options.Summary == "" && len(languages) == 0) {
+			fields := make([]huh.Field, 0)
+			if options.Title == "" {
+				fields = append(fields, huh.NewInput().Title("Title").Placeholder(defaultProjectTitle).Value(&projectTitle))
+			}
+			// When works are translated, a summary is asked for every language instead
+			if options.Summary == "" && len(languages) == 0 {
+				fields = append(fields, huh.NewText().Title("Summary").Description("A short description of the work").Placeholder(defaultSummary).Value(&summary))
+			}
+			if len(fields) > 0 {
+				groups = append(groups, huh.NewGroup(fields...))
+			}
+		}
+		if len(languages) > 0 && options.Summary == "" {
+			fields := make([]huh.Field, 0, len(languages))
+			for _, language := range languages {
+				if options.LocalizedSummaries[language] == "" {
+					fields = append(fields, huh.NewText().Title(fmt.Sprintf("Summary (%s)", language)).Description("A short description of the work, in that language. Leave empty to skip that language").Placeholder(defaultSummary).Value(localizedSummaries[language]))
+				}
+			}
+			if len(fields) > 0 {
+				groups = append(groups, huh.NewGroup(fields...))
+			}
+		}
+		if len(screenshots) > 0 {
+			groups = append(groups, huh.NewGroup(huh.NewMultiSelect[string]().
+				Title("Media").
+				Description("Screenshots to add to the description").
+				Options(huh.NewOptions(screenshots...)...).
+				Value(&screenshots)))
+		}
+		if options.Technologies == nil || options.Tags == nil {
+			fields := make([]huh.Field, 0)
+			if options.Technologies == nil {
+				fields = append(fields, huh.NewMultiSelect[string]().
+					Title("Technologies").
+					Description("What was the work made with?").
+					Filterable(true).
+					Value(&metadata.MadeWith).
+					Options(allTechsOptions...).
+					Validate(func(s []string) error {
+						ll.Debug("Selected %v", s)
+						return nil
+					}).
+					Height(2+6))
+			}
+			if options.Tags == nil {
+				fields = append(fields, huh.NewMultiSelect[string]().
+					Title("Tags").
+					Description("Categorize your work").
+					Filterable(true).
+					Value(&metadata.Tags).
+					Options(allTagsOptions...).
+					Height(2+6))
+			}
+			groups = append(groups, huh.NewGroup(fields...))
+		}
+		if options.Started == "" || (options.WIP == nil && options.Finished == "") {
+			fields := make([]huh.Field, 0)
+			if options.Started == "" {
+				fields = append(fields, huh.NewInput().Description("When did you start working on this?").Placeholder(startedAtPlaceholder).Value(&startedAt).Validate(validateDate))
+			}
+			if options.WIP == nil && options.Finished == "" {
+				fields = append(fields, huh.NewConfirm().Title("Work in progress").Description("What's the status?").Value(&metadata.WIP).Affirmative("WIP").Negative("Finished"))
+			}
+			groups = append(groups, huh.NewGroup(fields...))
+		}
+
+		if len(groups) > 0 {
+			err = huh.NewForm(groups...).Run()
+			if err != nil {
+				return outputPath, fmt.Errorf("while getting your answers: %w", err)
+			}
+		}
 	}
 
 	if !metadata.WIP {
 		defaultFinishedAt := time.Now().Format("2006-01-02")
-		if finishedAtFromGit, err := LastGitCommitDate(ctx.PathToWorkFolder(workId)); err == nil {
-			defaultFinishedAt = finishedAtFromGit.Format("2006-01-02")
-			ll.Log("Detected", "cyan", "finish date to be [bold][blue]%s[reset]", defaultFinishedAt)
+		if finishedAt == "" {
+			if finishedAtFromGit, err := LastGitCommitDate(workFolder); err == nil {
+				defaultFinishedAt = finishedAtFromGit.Format("2006-01-02")
+				ll.Log("Detected", "cyan", "finish date to be [bold][blue]%s[reset]", defaultFinishedAt)
+			}
 		}
 
-		err = huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().Description("When did you finish working on this?").Placeholder(defaultFinishedAt).Value(&metadata.Finished),
-			),
-		).Run()
-		if err != nil {
-			return outputPath, fmt.Errorf("while getting your answer: %w", err)
+		if finishedAt == "" && !options.NoInteractive {
+			err = huh.NewForm(
+				huh.NewGroup(
+					huh.NewInput().Description("When did you finish working on this?").Placeholder(defaultFinishedAt).Value(&finishedAt).Validate(validateDate),
+				),
+			).Run()
+			if err != nil {
+				return outputPath, fmt.Errorf("while getting your answer: %w", err)
+			}
 		}
 
-		if metadata.Finished == "" {
-			metadata.Finished = defaultFinishedAt
+		if finishedAt == "" {
+			finishedAt = defaultFinishedAt
+		}
+		metadata.Finished, err = ParseDateOrRange(finishedAt)
+		if err != nil {
+			return outputPath, err
 		}
 	}
 
@@ -298,39 +641,88 @@ func (ctx *RunContext) CreateDescriptionFile(workId string, metadataItems []stri
 		projectTitle = defaultProjectTitle
 	}
 
-	if metadata.Started == "" {
-		metadata.Started = defaultStartedAt
+	summaries := make(map[string]string)
+	for language, summary := range localizedSummaries {
+		if strings.TrimSpace(*summary) != "" {
+			summaries[language] = *summary
+		}
+	}
+	if summary == "" && len(summaries) == 0 {
+		summary = defaultSummary
+	}
+
+	// Paths of media blocks are relative to the description file
+	media := make([]string, 0, len(screenshots))
+	for _, screenshot := range screenshots {
+		relative, err := filepath.Rel(filepath.Dir(outputPath), filepath.Join(workFolder, screenshot))
+		if err != nil {
+			continue
+		}
+		media = append(media, filepath.ToSlash(relative))
+	}
+	if len(media) > 0 {
+		metadata.Thumbnail = FilePathInsidePortfolioFolder(media[0])
+	}
+	if hasManifest && manifest.License != "" {
+		metadata.AdditionalMetadata = map[string]any{"license": manifest.License}
+	}
+
+	if startedAt == "" {
+		startedAt = defaultStartedAt
+	}
+	metadata.Started, err = ParseDateOrRange(startedAt)
+	if err != nil {
+		return outputPath, err
 	}
 
 	// Construct the work metadata
-	for _, item := range metadataItems {
+	finishedBeforeItems := metadata.Finished
+	for _, item := range options.Metadata {
 		err := decodeMetadataItem(item, &metadata)
 		if err != nil {
 			return outputPath, fmt.Errorf("while decoding metadata item %q: %w", item, err)
 		}
 	}
+	// wip=true given as a metadata item, the default finish date does not apply
+	if metadata.WIP && options.Finished == "" && metadata.Finished == finishedBeforeItems {
+		metadata.Finished = Date{}
+	}
 
-	output += "---\n"
 	marshaledMetadata, err := yaml.Marshal(metadata)
 	if err != nil {
 		return outputPath, fmt.Errorf("while marshaling metadata of %s to yaml: %w", workId, err)
 	}
 
-	output += string(marshaledMetadata)
-	output += "---\n\n"
-
-	output += "# " + projectTitle + "\n\n"
-	output += summary + "\n\n"
-
-	if isGitRepo(ctx.PathToWorkFolder(workId)) {
-		remoteURL, err := gitRemoteURL(ctx.PathToWorkFolder(workId))
+	data := DescriptionTemplateData{
+		ID:                 workId,
+		Title:              projectTitle,
+		Summary:            summary,
+		LocalizedSummaries: summaries,
+		FrontMatter:        string(marshaledMetadata),
+		Metadata:           metadata,
+		Media:              media,
+		Homepage:           manifest.Homepage,
+	}
+	if isGitRepo(workFolder) {
+		remoteURL, err := gitRemoteURL(workFolder)
 		if err == nil {
-			output += fmt.Sprintf("[Source code](%s)\n\n", remoteURL)
+			data.SourceCodeURL = remoteURL
 		}
 	}
 
-	os.MkdirAll(filepath.Dir(outputPath), 0o755)
-	os.WriteFile(outputPath, []byte(output), 0o644)
+	output, err := RenderDescriptionTemplate(descriptionTemplate, data)
+	if err != nil {
+		return outputPath, fmt.Errorf("while rendering description template %s: %w", options.Template, err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(outputPath), 0o755)
+	if err != nil {
+		return outputPath, fmt.Errorf("while creating folder for description file: %w", err)
+	}
+	err = os.WriteFile(outputPath, []byte(output), 0o644)
+	if err != nil {
+		return outputPath, fmt.Errorf("while writing description file: %w", err)
+	}
 	ll.Log("Created", "green", "description.md file at [bold]%s[reset]", outputPath)
 	return outputPath, nil
 }
@@ -0,0 +1,135 @@
+package ortfodb
+
+import (
+	"fmt"
+	"sort"
+
+	ll "github.com/gwennlbh/label-logger-go"
+)
+
+// MinimumBlockSimilarity is how similar (see similarity) a block has to be to a block from the previous build to keep its ID.
+const MinimumBlockSimilarity = 0.6
+
+// StabilizeBlockIDs gives blocks IDs that stay the same when the description file is edited:
+//
+//   - named blocks (see ContentBlock.Name) use their name as their ID;
+//   - other blocks take the ID of the block of the same type from the previous build of the work, in the same language, that has the same content, or else the most similar content (if it is similar enough). Blocks that did not move are preferred;
+//   - remaining blocks keep the ID generated from their content.
+//
+// Blocks that end up with the same ID as a previous one (for example, two identical paragraphs) get a -2, -3, etc. suffix.
+func (ctx *RunContext) StabilizeBlockIDs(workID string, language string, blocks []ContentBlock) []ContentBlock {
+	previousBlocks := make([]ContentBlock, 0)
+	if previous, found := ctx.PreviouslyBuiltWork(workID); found {
+		previousBlocks = previous.Content.Localize(language).Blocks
+	}
+
+	stabilized := make([]ContentBlock, len(blocks))
+	copy(stabilized, blocks)
+	matched := make(map[int]bool)
+	previousMatched := make(map[int]bool)
+	keepID := func(block int, previous int) {
+		matched[block] = true
+		previousMatched[previous] = true
+		stabilized[block].ID = previousBlocks[previous].ID
+		ll.Debug("block %d of %s in %s keeps ID %s", block, workID, language, stabilized[block].ID)
+	}
+	canKeepID := func(block int, previous int) bool {
+		return !matched[block] && !previousMatched[previous] && blocks[block].Name == "" && previousBlocks[previous].Name == "" && blocks[block].Type == previousBlocks[previous].Type
+	}
+
+	// Unchanged blocks first, in order, so that identical blocks don't swap IDs
+	for i, block := range blocks {
+		for j, previous := range previousBlocks {
+			if canKeepID(i, j) && block.identityText() == previous.identityText() {
+				keepID(i, j)
+				break
+			}
+		}
+	}
+
+	// Then edited blocks, most similar first
+	type candidate struct {
+		block, previous int
+		score           float64
+	}
+	candidates := make([]candidate, 0)
+	positions := blockPositionsByType(blocks)
+	previousPositions := blockPositionsByType(previousBlocks)
+	for i, block := range blocks {
+		for j, previous := range previousBlocks {
+			if !canKeepID(i, j) {
+				continue
+			}
+			textSimilarity := similarity(block.identityText(), previous.identityText())
+			if textSimilarity < MinimumBlockSimilarity {
+				continue
+			}
+			positionSimilarity := 1 / float64(1+abs(positions[i]-previousPositions[j]))
+			candidates = append(candidates, candidate{i, j, 0.8*textSimilarity + 0.2*positionSimilarity})
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].score > candidates[b].score
+	})
+	for _, candidate := range candidates {
+		if canKeepID(candidate.block, candidate.previous) {
+			keepID(candidate.block, candidate.previous)
+		}
+	}
+
+	return disambiguateBlockIDs(stabilized, matched)
+}
+
+// disambiguateBlockIDs adds a -2, -3, etc. suffix to IDs that are already used by another block.
+// IDs of named blocks and of blocks in keep are never changed.
+func disambiguateBlockIDs(blocks []ContentBlock, keep map[int]bool) []ContentBlock {
+	seen := make(map[string]bool, len(blocks))
+	for i, block := range blocks {
+		if block.Name != "" || keep[i] {
+			seen[block.ID] = true
+		}
+	}
+	for i, block := range blocks {
+		if block.Name != "" || keep[i] {
+			continue
+		}
+		id := block.ID
+		for n := 2; seen[id]; n++ {
+			id = fmt.Sprintf("%s-%d", block.ID, n)
+		}
+		seen[id] = true
+		blocks[i].ID = id
+	}
+	return blocks
+}
+
+// blockPositionsByType returns the position of every block among the blocks of the same type, starting from 0.
+func blockPositionsByType(blocks []ContentBlock) []int {
+	positions := make([]int, len(blocks))
+	current := make(map[ContentBlockType]int)
+	for i, block := range blocks {
+		positions[i] = current[block.Type]
+		current[block.Type]++
+	}
+	return positions
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// identityText returns the text that identifies the block: its source for media, its text for paragraphs, and its URL for links.
+func (b ContentBlock) identityText() string {
+	switch b.Type {
+	case "media":
+		return string(b.Media.RelativeSource)
+	case "paragraph":
+		return b.Paragraph.Content.String()
+	case "link":
+		return b.Link.URL
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package ortfodb
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStabilizeBlockIDs(t *testing.T) {
+	paragraph := func(id, content string) ContentBlock {
+		return ContentBlock{ID: id, Type: "paragraph", Paragraph: Paragraph{Content: HTMLString(content)}}
+	}
+	media := func(id, source string) ContentBlock {
+		return ContentBlock{ID: id, Type: "media", Media: Media{RelativeSource: FilePathInsidePortfolioFolder(source)}}
+	}
+	named := func(block ContentBlock, name string) ContentBlock {
+		block.Name = name
+		return block
+	}
+
+	tests := []struct {
+		name     string
+		previous []ContentBlock
+		blocks   []ContentBlock
+		want     []string
+	}{
+		{
+			name:   "no previous build",
+			blocks: []ContentBlock{paragraph("a", "<p>Hello</p>"), media("b", "demo.mp4")},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:     "unchanged blocks that moved",
+			previous: []ContentBlock{paragraph("old1", "<p>Hello</p>"), media("old2", "demo.mp4")},
+			blocks:   []ContentBlock{media("new2", "demo.mp4"), paragraph("new1", "<p>Hello</p>")},
+			want:     []string{"old2", "old1"},
+		},
+		{
+			name:     "edited paragraph",
+			previous: []ContentBlock{paragraph("old", "<p>This is a paragraph about the project.</p>")},
+			blocks:   []ContentBlock{paragraph("new", "<p>This is a paragraph about this project.</p>")},
+			want:     []string{"old"},
+		},
+		{
+			name:     "rewritten paragraph",
+			previous: []ContentBlock{paragraph("old", "<p>This is a paragraph about the project.</p>")},
+			blocks:   []ContentBlock{paragraph("new", "<p>Something else entirely</p>")},
+			want:     []string{"new"},
+		},
+		{
+			name:     "blocks of another type",
+			previous: []ContentBlock{paragraph("old", "<p>demo.mp4</p>")},
+			blocks:   []ContentBlock{media("new", "demo.mp4")},
+			want:     []string{"new"},
+		},
+		{
+			name:     "identical blocks keep their order",
+			previous: []ContentBlock{paragraph("first", "<p>Same</p>"), paragraph("second", "<p>Same</p>")},
+			blocks:   []ContentBlock{paragraph("x", "<p>Same</p>"), paragraph("x", "<p>Same</p>"), paragraph("x", "<p>Same</p>")},
+			want:     []string{"first", "second", "x"},
+		},
+		{
+			name:   "duplicate generated IDs",
+			blocks: []ContentBlock{paragraph("x", "<p>Same</p>"), paragraph("x", "<p>Same</p>")},
+			want:   []string{"x", "x-2"},
+		},
+		{
+			name:     "named blocks",
+			previous: []ContentBlock{paragraph("old", "<p>Hello</p>")},
+			blocks:   []ContentBlock{named(paragraph("intro", "<p>Hello</p>"), "intro"), paragraph("intro", "<p>Bye</p>")},
+			want:     []string{"intro", "intro-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &RunContext{previousBuiltDatabase: PreviouslyBuiltDatabase{mu: &sync.Mutex{}, Database: Database{}}}
+			if tt.previous != nil {
+				ctx.previousBuiltDatabase.Database["work"] = Work{
+					ID:      "work",
+					Content: LocalizableContent{"en": {Blocks: tt.previous}},
+				}
+			}
+			got := make([]string, 0, len(tt.blocks))
+			for _, block := range ctx.StabilizeBlockIDs("work", "en", tt.blocks) {
+				got = append(got, block.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StabilizeBlockIDs() IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -21,6 +23,8 @@ import (
 
 	ll "github.com/gwennlbh/label-logger-go"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
 )
 
 type Database map[string]Work
@@ -28,6 +32,83 @@ type Database map[string]Work
 type DatabaseMeta struct {
 	// Partial is true if the database was not fully built.
 	Partial bool
+	// Shared by every work, so they are written once in the database file, under DatabaseMetaKey.
+	DatabaseRepositories `json:"-"`
+}
+
+// DatabaseRepositories are the tags and technologies repositories and the collections, which database files have under DatabaseMetaKey.
+type DatabaseRepositories struct {
+	// Collections of works, by ID. See Database.Collections.
+	Collections map[string]Collection `json:"collections"`
+	// Tags of the tags repository, with the number of works tagged with each of them. See Database.Tags.
+	Tags []TagUsage `json:"tags"`
+	// Technologies of the technologies repository, with the number of works made with each of them. See Database.Technologies.
+	Technologies []TechnologyUsage `json:"technologies"`
+}
+
+// DatabaseMetaKey is the key of the database file's object that holds the DatabaseRepositories, next to the works' IDs.
+const DatabaseMetaKey = "#meta"
+
+// jsonObject returns the database as it is written to database files: works by ID, and the DatabaseRepositories under DatabaseMetaKey.
+func (w Database) jsonObject() map[string]any {
+	object := make(map[string]any, len(w)+1)
+	for id, work := range w {
+		object[id] = work
+	}
+	if len(w) > 0 {
+		object[DatabaseMetaKey] = w.Meta().DatabaseRepositories
+	}
+	return object
+}
+
+func (w Database) MarshalJSON() ([]byte, error) {
+	return jsoniter.ConfigFastest.Marshal(w.jsonObject())
+}
+
+func (w *Database) UnmarshalJSON(data []byte) error {
+	json := jsoniter.ConfigFastest
+	object := make(map[string]jsoniter.RawMessage)
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	// Databases built by older versions don't have it
+	var repositories DatabaseRepositories
+	if raw, ok := object[DatabaseMetaKey]; ok {
+		if err := json.Unmarshal(raw, &repositories); err != nil {
+			return fmt.Errorf("while reading %s: %w", DatabaseMetaKey, err)
+		}
+		delete(object, DatabaseMetaKey)
+	}
+
+	*w = make(Database, len(object))
+	for id, raw := range object {
+		var work Work
+		if err := json.Unmarshal(raw, &work); err != nil {
+			return fmt.Errorf("while reading work %s: %w", id, err)
+		}
+		work.Metadata.DatabaseMetadata.DatabaseRepositories = repositories
+		(*w)[id] = work
+	}
+	return nil
+}
+
+// Localize returns the database metadata with tags, technologies and collections in the given language.
+func (m DatabaseMeta) Localize(language string) DatabaseMeta {
+	localized := m
+	localized.Collections = make(map[string]Collection, len(m.Collections))
+	for id, collection := range m.Collections {
+		localized.Collections[id] = collection.Localize(language)
+	}
+	localized.Tags = make([]TagUsage, len(m.Tags))
+	for i, tag := range m.Tags {
+		localized.Tags[i] = tag.Localize(language)
+	}
+	localized.Technologies = make([]TechnologyUsage, len(m.Technologies))
+	for i, technology := range m.Technologies {
+		localized.Technologies[i] = technology.Localize(language)
+	}
+	return localized
 }
 
 func (w Database) AsSlice() []Work {
@@ -109,6 +190,74 @@ func (w Database) Partial() bool {
 	return w.Meta().Partial
 }
 
+// Tags returns the tags of the tags repository, with the number of works tagged with each of them.
+func (w Database) Tags() []TagUsage {
+	if len(w) == 0 {
+		return []TagUsage{}
+	}
+	return w.Meta().Tags
+}
+
+// Tag returns the tag referred to by name, see Tag.ReferredToBy.
+func (w Database) Tag(name string) (tag TagUsage, found bool) {
+	for _, tag := range w.Tags() {
+		if tag.ReferredToBy(name) {
+			return tag, true
+		}
+	}
+	return TagUsage{}, false
+}
+
+// TagDescendants returns the children of the tag referred to by name, their own children, and so on.
+func (w Database) TagDescendants(name string) []TagUsage {
+	tag, found := w.Tag(name)
+	if !found {
+		return []TagUsage{}
+	}
+	descendants := make([]TagUsage, 0)
+	for _, childName := range tag.Children {
+		if child, found := w.Tag(childName); found {
+			descendants = append(descendants, child)
+			descendants = append(descendants, w.TagDescendants(child.Singular)...)
+		}
+	}
+	return descendants
+}
+
+// WorksTagged returns the works tagged with the tag referred to by name or with one of its descendants, in any language, with most recent works first.
+func (w Database) WorksTagged(name string) []Work {
+	tag, found := w.Tag(name)
+	if !found {
+		return []Work{}
+	}
+	tags := append([]TagUsage{tag}, w.TagDescendants(name)...)
+	works := make([]Work, 0)
+	for _, work := range w.WorksByDate() {
+		if some(work.Metadata.AllTags(), func(name string) bool { return some(tags, func(t TagUsage) bool { return t.ReferredToBy(name) }) }) {
+			works = append(works, work)
+		}
+	}
+	return works
+}
+
+// Technologies returns the technologies of the technologies repository, with the number of works made with each of them.
+func (w Database) Technologies() []TechnologyUsage {
+	if len(w) == 0 {
+		return []TechnologyUsage{}
+	}
+	return w.Meta().Technologies
+}
+
+// Technology returns the technology referred to by name, see Technology.ReferredToBy.
+func (w Database) Technology(name string) (technology TechnologyUsage, found bool) {
+	for _, technology := range w.Technologies() {
+		if technology.ReferredToBy(name) {
+			return technology, true
+		}
+	}
+	return TechnologyUsage{}, false
+}
+
 func (w Database) Languages() []string {
 	langs := make([]string, 0)
 	for _, work := range w {
@@ -139,7 +288,6 @@ type PreviouslyBuiltDatabase struct {
 }
 
 func (ctx *RunContext) PreviouslyBuiltDatabase() Database {
-	ctx.previousBuiltDatabase.mu = &sync.Mutex{}
 	ctx.previousBuiltDatabase.mu.Lock()
 	defer ctx.previousBuiltDatabase.mu.Unlock()
 	return ctx.previousBuiltDatabase.Database
@@ -187,6 +335,20 @@ type RunContext struct {
 
 	TagsRepository         []Tag
 	TechnologiesRepository []Technology
+	CollectionsRepository  []Collection
+
+	// Collections resolved from the built works, see ResolveCollections.
+	Collections map[string]Collection
+
+	// IDs and aliases of all works, used to check links to other works when linting.
+	knownWorks []string
+
+	// Files and manifests of works, used to detect technologies and tags. See scanWork.
+	workScans map[string]*workScan
+
+	// Markdown parser and HTML sanitization policy, created from the configuration on first use.
+	markdown  goldmark.Markdown
+	sanitizer *bluemonday.Policy
 }
 
 type Flags struct {
@@ -199,6 +361,7 @@ type Flags struct {
 	ProgressInfoFile string
 	ExportersToUse   []string
 	ImportersToUse   []string
+	Detect           bool
 }
 
 // Project represents a project.
@@ -235,13 +398,13 @@ func ReleaseBuildLock(outputFilename string) error {
 	return err
 }
 
-func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags, config Configuration) (*RunContext, error) {
+// NewRunContext creates a run context for the given database directory, without side effects: unlike PrepareBuild, no build lock is acquired, no files are written and no exporter or importer is run.
+func NewRunContext(databaseDirectory string, flags Flags, config Configuration) *RunContext {
 	ctx := RunContext{
-		Config:             &config,
-		Flags:              flags,
-		DatabaseDirectory:  databaseDirectory,
-		OutputDatabaseFile: outputFilename,
-		ProgressInfoFile:   flags.ProgressInfoFile,
+		Config:            &config,
+		Flags:             flags,
+		DatabaseDirectory: databaseDirectory,
+		ProgressInfoFile:  flags.ProgressInfoFile,
 		previousBuiltDatabase: PreviouslyBuiltDatabase{
 			mu:       &sync.Mutex{},
 			Database: make(Database),
@@ -259,6 +422,12 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 	}
 
 	ll.Debug("Using %d thumbnailers threads per work", ctx.thumbnailersPerWork)
+	return &ctx
+}
+
+func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags, config Configuration) (*RunContext, error) {
+	ctx := NewRunContext(databaseDirectory, flags, config)
+	ctx.OutputDatabaseFile = outputFilename
 
 	if ctx.ProgressInfoFile != "" {
 		ll.Debug("Removing progress info file %s", ctx.ProgressInfoFile)
@@ -275,7 +444,7 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 	for _, exporterName := range exportersToUse {
 		exporter, err := ctx.FindExporter(exporterName)
 		if err != nil {
-			return &ctx, fmt.Errorf("while finding exporter %s: %w", exporterName, err)
+			return ctx, fmt.Errorf("while finding exporter %s: %w", exporterName, err)
 		}
 
 		ctx.Exporters = append(ctx.Exporters, exporter)
@@ -289,13 +458,13 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 	for _, importerName := range importersToUse {
 		importer, err := ctx.FindImporter(importerName)
 		if err != nil {
-			return &ctx, fmt.Errorf("while finding importer %s: %w", importerName, err)
+			return ctx, fmt.Errorf("while finding importer %s: %w", importerName, err)
 		}
 
 		ctx.Importers = append(ctx.Importers, importer)
 	}
 
-	ll.Debug("Running with configuration %#v", &config)
+	ll.Debug("Running with configuration %#v", ctx.Config)
 
 	previousBuiltDatabaseRaw, err := os.ReadFile(outputFilename)
 	if err != nil {
@@ -308,7 +477,7 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 		if err != nil {
 			ll.ErrorDisplay("Couldn't use previous built database file %s", err, outputFilename)
 		}
-		ctx.previousBuiltDatabase = PreviouslyBuiltDatabase{Database: previousDb}
+		ctx.previousBuiltDatabase.Database = previousDb
 	}
 
 	if ctx.Config.IsDefault {
@@ -317,23 +486,23 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 
 	err = os.MkdirAll(config.Media.At, 0o755)
 	if err != nil {
-		return &ctx, fmt.Errorf("while creating the media output directory: %w", err)
+		return ctx, fmt.Errorf("while creating the media output directory: %w", err)
 	}
 	if err := AcquireBuildLock(outputFilename); err != nil {
-		return &ctx, fmt.Errorf("another ortfo build is in progress (could not acquire build lock): %w", err)
+		return ctx, fmt.Errorf("another ortfo build is in progress (could not acquire build lock): %w", err)
 	}
 
 	ll.Debug("handling exporters")
 	for _, exporter := range ctx.Exporters {
 		options, err := ctx.ExporterOptions(exporter)
 		if err != nil {
-			return &ctx, err
+			return ctx, err
 		}
 
 		ll.Log("Using", "magenta", "exporter [bold]%s[reset]\n[dim]%s", exporter.Name(), exporter.Description())
-		err = exporter.Before(&ctx, options)
+		err = exporter.Before(ctx, options)
 		if err != nil {
-			return &ctx, fmt.Errorf("while running exporter %s before hook: %w", exporter.Name(), err)
+			return ctx, fmt.Errorf("while running exporter %s before hook: %w", exporter.Name(), err)
 		}
 	}
 
@@ -341,20 +510,20 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 	for _, importer := range ctx.Importers {
 		options, err := ctx.ImporterOptions(importer)
 		if err != nil {
-			return &ctx, err
+			return ctx, err
 		}
 
 		ll.Log("Using", "magenta", "importer [bold]%s[reset]\n[dim]%s", importer.Name(), importer.Description())
-		toImport, err := importer.List(&ctx, options)
+		toImport, err := importer.List(ctx, options)
 		if err != nil {
-			return &ctx, fmt.Errorf("while listing works to import with importer %s: %w", importer.Name(), err)
+			return ctx, fmt.Errorf("while listing works to import with importer %s: %w", importer.Name(), err)
 		}
 
 		ll.Log("Importing", "magenta", "%d works with %s", len(toImport), importer.Name())
 
 		for _, workId := range toImport {
 			if _, err := os.Stat(filepath.Join(ctx.DatabaseDirectory, workId)); os.IsNotExist(err) {
-				err = importer.Import(&ctx, options, workId)
+				err = importer.Import(ctx, options, workId)
 				if err != nil {
 					ll.WarnDisplay("could not import %q with %s", err, workId, importer.Name())
 				}
@@ -364,7 +533,7 @@ func PrepareBuild(databaseDirectory string, outputFilename string, flags Flags,
 		}
 	}
 
-	return &ctx, nil
+	return ctx, nil
 }
 
 // BuildAll builds the database at outputFilename from databaseDirectory.
@@ -415,8 +584,8 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 	}
 
 	// Initialize stuff
-	works := ctx.PreviouslyBuiltDatabase()
-	// ll.Debug("initialized works@%p from previous@%p", works, ctx.previousBuiltDatabase.Database)
+	// works is a copy, since workers read the previous build while works are collected
+	works := maps.Clone(ctx.PreviouslyBuiltDatabase())
 	workDirectories, err := ctx.ComputeProgressTotal()
 	if err != nil {
 		return Database{}, fmt.Errorf("while computing total number of works to build: %w", err)
@@ -425,9 +594,14 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 	for _, dirEntry := range workDirectories {
 		workDirectoriesNames = append(workDirectoriesNames, dirEntry.Name())
 	}
+	if stringInSlice(workDirectoriesNames, DatabaseMetaKey) {
+		return Database{}, fmt.Errorf("a work can't be named %s, since the database uses this key for tags, technologies and collections", DatabaseMetaKey)
+	}
 	workDirectoriesChannel := make(chan os.DirEntry, len(workDirectories))
 	builtChannel := make(chan builtItem)
 	builtDirectories := make([]string, 0)
+	// IDs of the works that were built, as opposed to reused from the previous build
+	freshlyBuilt := make([]string, 0)
 
 	if flags.WorkersCount <= 0 {
 		flags.WorkersCount = runtime.NumCPU()
@@ -485,7 +659,6 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 						continue
 					}
 
-					ctx.RunExporters(&newWork)
 					if usedCache {
 						ctx.Status(workID, PhaseUnchanged)
 					} else {
@@ -526,9 +699,8 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 		}
 		if !result.reuseOld {
 			ll.Debug("main: updating work %s", result.workID)
-			ctx.previousBuiltDatabase.mu.Lock()
 			works[result.workID] = result.work
-			ctx.previousBuiltDatabase.mu.Unlock()
+			freshlyBuilt = append(freshlyBuilt, result.workID)
 		}
 		ctx.WriteDatabase(works, flags, outputFilename, true)
 		builtDirectories = append(builtDirectories, result.workID)
@@ -536,6 +708,59 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 		ll.Debug("main: left to build: %v", directoriesLeftToBuild(workDirectoriesNames, builtDirectories))
 	}
 
+	// Forget about works whose folder was removed or renamed since the previous build, so that links and relations to them are caught
+	for workID := range works {
+		if !stringInSlice(workDirectoriesNames, workID) {
+			ll.Debug("main: removing work %s, its folder does not exist anymore", workID)
+			delete(works, workID)
+		}
+	}
+
+	err = ctx.ResolveRelations(works)
+	if err != nil {
+		return works, fmt.Errorf("while resolving relations: %w", err)
+	}
+
+	err = ctx.ResolveWorkLinks(works)
+	if err != nil {
+		return works, fmt.Errorf("while resolving links to other works: %w", err)
+	}
+
+	collections, err := ctx.ResolveCollections(works)
+	if err != nil {
+		return works, fmt.Errorf("while resolving collections: %w", err)
+	}
+	ctx.Collections = collections
+
+	if ctx.Config.Detection.AtBuildTime || ctx.Flags.Detect {
+		err = ctx.DetectInWorks(works)
+		if err != nil {
+			return works, fmt.Errorf("while detecting technologies and tags: %w", err)
+		}
+	} else {
+		ForgetDetected(works)
+	}
+
+	err = ctx.CanonicalizeTagsAndTechnologies(works)
+	if err != nil {
+		return works, fmt.Errorf("while canonicalizing tags and technologies: %w", err)
+	}
+
+	meta := DatabaseMeta{DatabaseRepositories: ctx.databaseRepositories(works)}
+	for id, work := range works {
+		work.Metadata.DatabaseMetadata = meta
+		works[id] = work
+	}
+
+	// Export works only now that links, relations, collections and detected tags are resolved
+	for _, workID := range freshlyBuilt {
+		work := works[workID]
+		if err := ctx.RunExporters(&work); err != nil {
+			ll.ErrorDisplay("while exporting %s", err, workID)
+		}
+		works[workID] = work
+	}
+
 	for _, exporter := range ctx.Exporters {
 		options := ctx.Config.Exporters[exporter.Name()]
 		ll.Debug("Running exporter %s's after hook with options %#v", exporter.Name(), options)
@@ -549,11 +774,19 @@ func (ctx *RunContext) BuildSome(include string, databaseDirectory string, outpu
 	return works, nil
 }
 
+func (ctx *RunContext) databaseRepositories(works Database) DatabaseRepositories {
+	return DatabaseRepositories{
+		Collections:  ctx.Collections,
+		Tags:         ctx.TagsUsage(works),
+		Technologies: ctx.TechnologiesUsage(works),
+	}
+}
+
 func (ctx *RunContext) WriteDatabase(works Database, flags Flags, outputFilename string, partial bool) {
 	ll.Debug("Writing database (partial=%v) to %s", partial, outputFilename)
 	worksWithDatabaseMetadata := make(Database, 0)
 	for id, work := range works {
-		work.Metadata.DatabaseMetadata = DatabaseMeta{Partial: partial}
+		work.Metadata.DatabaseMetadata.Partial = partial
 		worksWithDatabaseMetadata[id] = work
 	}
 
@@ -561,9 +794,9 @@ func (ctx *RunContext) WriteDatabase(works Database, flags Flags, outputFilename
 	var worksJSON []byte
 	json := jsoniter.ConfigFastest
 	if ctx.Flags.Minified {
-		worksJSON, _ = json.Marshal(worksWithDatabaseMetadata)
+		worksJSON, _ = json.Marshal(worksWithDatabaseMetadata.jsonObject())
 	} else {
-		worksJSON, _ = json.MarshalIndent(worksWithDatabaseMetadata, "", "    ")
+		worksJSON, _ = json.MarshalIndent(worksWithDatabaseMetadata.jsonObject(), "", "    ")
 	}
 
 	// Output it
@@ -638,7 +871,7 @@ func (ctx *RunContext) DescriptionFilename(databaseDirectory string, workID stri
 }
 
 // Build builds a single work given the database & output folders, as wells as a work ID.
-// BuiltAt and DescriptionHash are set.
+// BuiltAt, FirstBuiltAt, DescriptionChangedAt and DescriptionHash are set.
 func (ctx *RunContext) Build(descriptionRaw string, outputFilename string, workID string) (work Work, usedCache bool, err error) {
 	hash := md5.Sum([]byte(descriptionRaw))
 	newDescriptionHash := base64.StdEncoding.EncodeToString(hash[:])
@@ -646,6 +879,12 @@ func (ctx *RunContext) Build(descriptionRaw string, outputFilename string, workI
 	if oldWork, found := ctx.PreviouslyBuiltWork(workID); found && oldWork.DescriptionHash == newDescriptionHash && !ctx.Flags.NoCache {
 		ll.Debug("parsing description for %s: using cached work", workID)
 		work = oldWork
+		// Media blocks are updated below, don't update the previous build's content blocks, which are read by other works' builds
+		work.Content = make(LocalizableContent, len(oldWork.Content))
+		for language, content := range oldWork.Content {
+			content.Blocks = slices.Clone(content.Blocks)
+			work.Content[language] = content
+		}
 		usedCache = true
 	} else {
 		work, err = ParseDescription(ctx, string(descriptionRaw), workID)
@@ -671,7 +910,9 @@ func (ctx *RunContext) Build(descriptionRaw string, outputFilename string, workI
 
 			usedCache = usedCache && usedCacheForMedia
 			work.Content[lang].Blocks[i].Media = analyzed
-			work.Content[lang].Blocks[i].Anchor = anchor
+			if block.Name == "" {
+				work.Content[lang].Blocks[i].Anchor = anchor
+			}
 			analyzedMediae = append(analyzedMediae, analyzed)
 		}
 	}
@@ -699,6 +940,21 @@ func (ctx *RunContext) Build(descriptionRaw string, outputFilename string, workI
 		work.BuiltAt = time.Now()
 	}
 
+	// Works built before these dates were tracked use the time of their previous build instead
+	work.FirstBuiltAt, work.DescriptionChangedAt = work.BuiltAt, work.BuiltAt
+	if oldWork, found := ctx.PreviouslyBuiltWork(workID); found {
+		work.FirstBuiltAt = oldWork.FirstBuiltAt
+		if work.FirstBuiltAt.IsZero() {
+			work.FirstBuiltAt = oldWork.BuiltAt
+		}
+		if oldWork.DescriptionHash == newDescriptionHash {
+			work.DescriptionChangedAt = oldWork.DescriptionChangedAt
+			if work.DescriptionChangedAt.IsZero() {
+				work.DescriptionChangedAt = oldWork.BuiltAt
+			}
+		}
+	}
+
 	// Return the finished work
 	return work, usedCache, nil
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"github.com/MakeNowJust/heredoc"
@@ -12,18 +14,49 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var overwrite bool
+var addOptions ortfodb.AddOptions
+var addWIP bool
+var addLocalizedSummaries []string
 
 func init() {
-	addCmd.PersistentFlags().BoolVar(&overwrite, "overwrite", false, "Overwrite the description.md file if it already exists")
+	addCmd.PersistentFlags().BoolVar(&addOptions.Overwrite, "overwrite", false, "Overwrite the description.md file if it already exists")
+	addCmd.Flags().StringVar(&addOptions.Title, "title", "", "Title of the work")
+	addCmd.Flags().StringVar(&addOptions.Summary, "summary", "", "Short description of the work")
+	addCmd.Flags().StringArrayVar(&addLocalizedSummaries, "localized-summary", nil, "Short description of the work in another language, as language=summary. Can be repeated")
+	addCmd.Flags().StringSliceVar(&addOptions.Tags, "tags", nil, "Tags of the work, separated by commas. Disables tags detection")
+	addCmd.Flags().StringSliceVar(&addOptions.Technologies, "technologies", nil, "Technologies the work was made with, separated by commas. Disables technologies detection")
+	addCmd.Flags().StringVar(&addOptions.Started, "started", "", "When the work was started (YYYY-MM-DD, YYYY-MM or YYYY)")
+	addCmd.Flags().StringVar(&addOptions.Finished, "finished", "", "When the work was finished (YYYY-MM-DD, YYYY-MM or YYYY)")
+	addCmd.Flags().BoolVar(&addWIP, "wip", false, "Mark the work as a work in progress. Use --wip=false to mark it as finished")
+	addCmd.Flags().StringArrayVar(&addOptions.Metadata, "metadata", nil, "Additional metadata, as key=value. Can be repeated")
+	addCmd.Flags().BoolVar(&addOptions.NoInteractive, "no-interactive", false, "Don't ask anything: use detected values or defaults for what is not given with flags")
+	addCmd.Flags().StringVar(&addOptions.Template, "template", "", "Template to create the description file from: a name of a template in the add.templates directory, or a path to a template file")
+	addCmd.RegisterFlagCompletionFunc("template", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		config, err := ortfodb.NewConfiguration(flags.Config)
+		if err != nil || config.Add.Templates == "" {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		entries, _ := os.ReadDir(config.Add.Templates)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if filepath.Ext(entry.Name()) == ".md" {
+				names = append(names, strings.TrimSuffix(entry.Name(), ".md"))
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
 	rootCmd.AddCommand(addCmd)
 }
 
 var addCmd = &cobra.Command{
 	Use:   "add <id>",
 	Short: "Add a new project to your portfolio",
-	Long:  heredoc.Doc(`Create a new project in the appropriate folder. ID is the work's slug.`),
-	Args:  cobra.MinimumNArgs(1),
+	Long: heredoc.Doc(`Create a new project in the appropriate folder. ID is the work's slug.
+
+	Values that are not given with flags are asked for, with detected values as defaults. Use --no-interactive to use detected values directly, for example when creating works from scripts.
+	`),
+	Example: "ortfodb add my-work --no-interactive --title \"My work\" --tags web,design --wip --metadata \"page background=#000\"",
+	Args:    cobra.MinimumNArgs(1),
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		config, err := ortfodb.NewConfiguration(flags.Config)
 		if err != nil {
@@ -54,10 +87,19 @@ var addCmd = &cobra.Command{
 
 		projectId := args[0]
 
-		// TODO
-		metadataItems := []string{}
+		if cmd.Flags().Changed("wip") {
+			addOptions.WIP = &addWIP
+		}
+		addOptions.LocalizedSummaries = make(map[string]string, len(addLocalizedSummaries))
+		for _, item := range addLocalizedSummaries {
+			language, summary, found := strings.Cut(item, "=")
+			if !found {
+				handleError(fmt.Errorf("localized summary %q should be of the form language=summary", item))
+			}
+			addOptions.LocalizedSummaries[strings.TrimSpace(language)] = summary
+		}
 
-		descriptionFilepath, err := context.CreateDescriptionFile(projectId, metadataItems, overwrite)
+		descriptionFilepath, err := context.CreateDescriptionFile(projectId, addOptions)
 		if err != nil {
 			ortfodb.ReleaseBuildLock(ortfodb.BuildLockFilepath("./fictional.json"))
 			handleError(fmt.Errorf("while creating description file: %w", err))
@@ -69,7 +111,7 @@ var addCmd = &cobra.Command{
 		}
 
 		editor := os.Getenv("EDITOR")
-		if editor != "" {
+		if editor != "" && !addOptions.NoInteractive {
 			ll.Log("Opening", "cyan", "%s in %s", descriptionFilepath, editor)
 			editorPath, err := exec.LookPath(editor)
 			if err != nil {
@@ -15,6 +15,7 @@ func init() {
 	buildCmd.PersistentFlags().StringVar(&flags.ProgressInfoFile, "write-progress", "", "Write progress information to a file. See https://pkg.go.dev/github.com/ortfo/db#ProgressInfoEvent for more information.")
 	buildCmd.PersistentFlags().BoolVar(&flags.NoCache, "no-cache", false, "Disable usage of previous database build as cache for this build (used for media analysis among other things).")
 	buildCmd.PersistentFlags().IntVar(&flags.WorkersCount, "workers", runtime.NumCPU(), "Choose the number of workers to build the database. Defaults to the number of CPU cores.")
+	buildCmd.PersistentFlags().BoolVar(&flags.Detect, "detect", false, "Detect technologies and tags of every work, and add the ones that are not declared in description files to the database. See the detection.at build time configuration option.")
 	buildCmd.PersistentFlags().StringArrayVarP(&flags.ExportersToUse, "exporters", "e", []string{}, "Exporters to enable. If not provided, all the exporters configured in the configuration file will be enabled.")
 	buildCmd.RegisterFlagCompletionFunc("exporters", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		config, err := ortfodb.NewConfiguration(flags.Config)
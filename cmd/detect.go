@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	ll "github.com/gwennlbh/label-logger-go"
+	ortfodb "github.com/ortfo/db"
+	"github.com/spf13/cobra"
+)
+
+var detectFormat string
+
+// detectSuggestion is a technology or tag detected by the detect command, as written with --format json.
+type detectSuggestion struct {
+	Name      string            `json:"name"`
+	Detection ortfodb.Detection `json:"detection"`
+	// Whether the description file declares it already.
+	Declared bool `json:"declared"`
+}
+
+var detectCmd = &cobra.Command{
+	Use:   "detect <work>",
+	Short: "Preview the technologies and tags detected for a work",
+	Long: heredoc.Doc(`Detect the technologies and tags of a work from the files in its folder, using the technologies and tags repositories, and show why each of them was detected.
+
+	Technologies and tags that the work's description file declares already are marked as such. Others are the ones that would be added to the database when building with --detect.
+	`),
+	Example: "ortfodb detect my-work --format json",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		workID := args[0]
+		config, err := ortfodb.NewConfiguration(flags.Config)
+		if err != nil {
+			handleError(err)
+		}
+
+		ctx := ortfodb.NewRunContext(config.ProjectsDirectory, flags, config)
+		if info, err := os.Stat(filepath.Join(ctx.DatabaseDirectory, workID)); err != nil || !info.IsDir() {
+			handleError(fmt.Errorf("no work named %s in %s", workID, ctx.DatabaseDirectory))
+		}
+
+		_, err = ctx.LoadTechnologiesRepository()
+		if err != nil {
+			handleError(fmt.Errorf("while loading technologies repository: %w", err))
+		}
+		_, err = ctx.LoadTagsRepository()
+		if err != nil {
+			handleError(fmt.Errorf("while loading tags repository: %w", err))
+		}
+
+		// Works that don't have a description file yet declare nothing
+		metadata := ortfodb.WorkMetadata{}
+		if raw, err := os.ReadFile(ctx.DescriptionFilename(ctx.DatabaseDirectory, workID)); err == nil {
+			metadata, _, _, err = ortfodb.ParseFrontMatter[ortfodb.WorkMetadata](string(raw))
+			if err != nil {
+				handleError(fmt.Errorf("while parsing description file of %s: %w", workID, err))
+			}
+		}
+
+		technologies, tags, err := ctx.DetectMetadata(workID, metadata)
+		if err != nil {
+			handleError(err)
+		}
+
+		suggestions := map[string][]detectSuggestion{"madeWith": {}, "tags": {}}
+		for _, tech := range technologies {
+			suggestions["madeWith"] = append(suggestions["madeWith"], detectSuggestion{tech.Slug, tech.Detection, metadata.DeclaresTechnology(tech.Technology)})
+		}
+		for _, tag := range tags {
+			suggestions["tags"] = append(suggestions["tags"], detectSuggestion{tag.Singular, tag.Detection, ctx.DeclaresTag(metadata, tag.Tag)})
+		}
+
+		switch detectFormat {
+		case "text":
+			for _, kind := range []struct{ key, singular, plural string }{{"madeWith", "technology", "technologies"}, {"tags", "tag", "tags"}} {
+				if len(suggestions[kind.key]) == 0 {
+					ll.Log("Detected", "cyan", "no %s", kind.plural)
+				}
+				for _, suggestion := range suggestions[kind.key] {
+					declared := ""
+					if suggestion.Declared {
+						declared = " [green](declared)[reset]"
+					}
+					ll.Log("Detected", "cyan", "%s [bold][blue]%s[reset]%s [dim]%s[reset]", kind.singular, suggestion.Name, declared, suggestion.Detection)
+				}
+			}
+		case "json":
+			out, err := json.MarshalIndent(suggestions, "", "  ")
+			handleError(err)
+			fmt.Println(string(out))
+		default:
+			handleError(fmt.Errorf("unknown output format %q, use one of text or json", detectFormat))
+		}
+	},
+}
+
+func init() {
+	detectCmd.Flags().StringVarP(&detectFormat, "format", "f", "text", "Output format: text or json")
+	detectCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(detectCmd)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/MakeNowJust/heredoc"
+	ll "github.com/gwennlbh/label-logger-go"
+	ortfodb "github.com/ortfo/db"
+	"github.com/spf13/cobra"
+)
+
+var diffFlags struct {
+	format   string
+	noVerify bool
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-filepath> <new-filepath>",
+	Short: "Show what changed between two builds of a database",
+	Long: heredoc.Doc(`Compare two built database files, work by work.
+
+	Reports works that were added or removed, changed metadata fields, content blocks that were added, removed or modified in each language (blocks are matched by ID), layout changes, and media files that changed or whose thumbnails were regenerated.
+
+	Exits with status code 0 if nothing changed, 1 if something changed and 2 if the databases could not be compared, like diff(1).
+	`),
+	Example: heredoc.Doc(`
+	ortfodb diff deployed.json database.json
+	ortfodb diff --format json deployed.json database.json > changes.json
+	`),
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// handleError exits with 1, which means that something changed
+		fail := func(err error) {
+			ll.ErrorDisplay("", err)
+			os.Exit(2)
+		}
+
+		databases := make([]ortfodb.Database, 0, len(args))
+		for _, filepath := range args {
+			database, err := ortfodb.LoadDatabase(filepath, diffFlags.noVerify)
+			if err != nil {
+				fail(fmt.Errorf("while loading database %s: %w", filepath, err))
+			}
+			databases = append(databases, database)
+		}
+
+		diff := ortfodb.DiffDatabases(databases[0], databases[1])
+
+		switch diffFlags.format {
+		case "text":
+			if diff.HasChanges() {
+				fmt.Println(diff)
+			}
+			ll.Log("Compared", "green", "%d added, %d removed and %d changed works", len(diff.Added), len(diff.Removed), len(diff.Changed))
+		case "json":
+			out, err := diff.JSON()
+			if err != nil {
+				fail(err)
+			}
+			fmt.Println(string(out))
+		default:
+			fail(fmt.Errorf("unknown output format %q, use one of text or json", diffFlags.format))
+		}
+
+		if diff.HasChanges() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFlags.format, "format", "f", "text", "Output format: text or json")
+	diffCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	diffCmd.Flags().BoolVarP(&diffFlags.noVerify, "no-verify", "n", false, "Don't try to validate the database files before comparing them")
+	rootCmd.AddCommand(diffCmd)
+}
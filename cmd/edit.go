@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc"
+	ll "github.com/gwennlbh/label-logger-go"
+	ortfodb "github.com/ortfo/db"
+	"github.com/spf13/cobra"
+)
+
+var editFlags struct {
+	operations []ortfodb.EditOperation
+	where      []string
+	dryRun     bool
+}
+
+// editOperationFlag adds operations of its kind to editFlags.operations, so that they are applied in the order they are given on the command line.
+type editOperationFlag struct {
+	kind      ortfodb.EditOperationKind
+	arguments []string
+}
+
+func (f *editOperationFlag) Set(argument string) error {
+	operation, err := ortfodb.ParseEditOperation(f.kind, argument)
+	if err != nil {
+		return err
+	}
+	f.arguments = append(f.arguments, argument)
+	editFlags.operations = append(editFlags.operations, operation)
+	return nil
+}
+
+func (f *editOperationFlag) String() string {
+	return "[" + strings.Join(f.arguments, ",") + "]"
+}
+
+func (f *editOperationFlag) Type() string {
+	return "stringArray"
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit [works...]",
+	Short: "Change the metadata of many works at once",
+	Long: heredoc.Doc(`Change the front matter of the description files of the given works (all works if none are given).
+
+	works are work IDs, glob patterns are supported. Use --where to only edit works that have some metadata value.
+
+	Operations are applied in the order they are given, and each of them can be repeated.
+
+	Only the front matter is rewritten: the order of keys, comments and the rest of the file are kept. Only YAML front matters can be edited.
+	`),
+	Example: heredoc.Doc(`
+	ortfodb edit --rename-tag website=web --dry-run
+	ortfodb edit 'game-*' --add tags=game --set wip=false
+	ortfodb edit --where "made with=vue" --replace-technology vue=svelte
+	`),
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := ortfodb.NewConfiguration(flags.Config)
+		if err != nil {
+			handleError(err)
+		}
+
+		if len(editFlags.operations) == 0 {
+			handleError(fmt.Errorf("nothing to do, use at least one of --set, --unset, --add, --remove, --rename-tag or --replace-technology"))
+		}
+
+		filters := make([]ortfodb.EditFilter, 0, len(editFlags.where))
+		for _, where := range editFlags.where {
+			filter, err := ortfodb.ParseEditFilter(where)
+			if err != nil {
+				handleError(err)
+			}
+			filters = append(filters, filter)
+		}
+
+		ctx := ortfodb.NewRunContext(config.ProjectsDirectory, flags, config)
+		results, err := ctx.Edit(args, filters, editFlags.operations, editFlags.dryRun)
+		if err != nil {
+			handleError(err)
+		}
+
+		edited := 0
+		for _, result := range results {
+			if !result.Changed() {
+				ll.Debug("Nothing to change in %s", result.WorkID)
+				continue
+			}
+			edited++
+			if editFlags.dryRun {
+				fmt.Print(result.Diff())
+			} else {
+				ll.Log("Edited", "green", "%s", result.File)
+			}
+		}
+
+		verb := "Edited"
+		if editFlags.dryRun {
+			verb = "Would edit"
+		}
+		ll.Log(verb, "green", "%d of %d selected works", edited, len(results))
+	},
+}
+
+func init() {
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditSet}, "set", "Set a metadata value, as key=value. The value is parsed as YAML")
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditUnset}, "unset", "Remove a metadata key")
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditAdd}, "add", "Add a value to a list, as key=value. For example tags=web")
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditRemove}, "remove", "Remove a value from a list, as key=value")
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditRenameTag}, "rename-tag", "Rename a tag, as old=new, in the work's tags and in their translations. Aliases of the old tag in the tags repository are renamed too")
+	editCmd.Flags().Var(&editOperationFlag{kind: ortfodb.EditReplaceTechnology}, "replace-technology", "Replace a technology, as old=new. Aliases of the old technology in the technologies repository are replaced too")
+	editCmd.Flags().StringArrayVar(&editFlags.where, "where", nil, "Only edit works whose metadata at key is (or contains) value, as key=value. Can be repeated, works must match all of them")
+	editCmd.Flags().BoolVar(&editFlags.dryRun, "dry-run", false, "Show what would change as a diff, without writing anything")
+	rootCmd.AddCommand(editCmd)
+}
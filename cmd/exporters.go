@@ -115,6 +115,10 @@ func exporterDetails(exporter ortfodb.Exporter) (name, description string, requi
 			decoder.Decode(&ortfodb.SqlExporterOptions{})
 		case *ortfodb.LocalizeExporter:
 			decoder.Decode(&ortfodb.LocalizeExporterOptions{})
+		case *ortfodb.FeedsExporter:
+			decoder.Decode(&ortfodb.FeedsExporterOptions{})
+		case *ortfodb.SitemapExporter:
+			decoder.Decode(&ortfodb.SitemapExporterOptions{})
 		}
 
 		return exporter.Name(), exporter.Description(), []string{}, options
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc"
+	ll "github.com/gwennlbh/label-logger-go"
+	ortfodb "github.com/ortfo/db"
+	"github.com/spf13/cobra"
+)
+
+var lintFormat string
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [works...]",
+	Short: "Check description files for problems",
+	Long: heredoc.Doc(`Check the description files of the given works (all works if none are given) for problems, without building the database.
+
+	works are work IDs, glob patterns are supported.
+
+	Reports missing alt texts, media files that don't exist, tags and technologies that are not in their repositories, invalid dates, layout block references that don't resolve, languages that are missing some blocks and broken links.
+
+	Exits with a non-zero status code if any problem has the error severity.
+	`),
+	Example: "ortfodb lint --format sarif > ortfodb.sarif",
+	Run: func(cmd *cobra.Command, args []string) {
+		config, err := ortfodb.NewConfiguration(flags.Config)
+		if err != nil {
+			handleError(err)
+		}
+
+		ctx := ortfodb.NewRunContext(config.ProjectsDirectory, flags, config)
+		diagnostics, err := ctx.Lint(args...)
+		if err != nil {
+			handleError(err)
+		}
+
+		// Show paths relative to the current directory when possible, as expected by editors and CI tools.
+		if cwd, err := os.Getwd(); err == nil {
+			for i, diagnostic := range diagnostics {
+				if relative, err := filepath.Rel(cwd, diagnostic.File); err == nil {
+					diagnostics[i].File = relative
+				}
+			}
+		}
+
+		switch lintFormat {
+		case "text":
+			for _, diagnostic := range diagnostics {
+				fmt.Println(diagnostic)
+			}
+			if len(diagnostics) == 0 {
+				ll.Log("Checked", "green", "no problems found")
+			}
+		case "json":
+			out, err := diagnostics.JSON()
+			handleError(err)
+			fmt.Println(string(out))
+		case "sarif":
+			out, err := diagnostics.SARIF()
+			handleError(err)
+			fmt.Println(string(out))
+		default:
+			handleError(fmt.Errorf("unknown output format %q, use one of text, json or sarif", lintFormat))
+		}
+
+		if diagnostics.HasErrors() {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", "text", "Output format: text, json or sarif")
+	lintCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"text", "json", "sarif"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(lintCmd)
+}
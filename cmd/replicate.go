@@ -9,13 +9,16 @@ import (
 )
 
 var force bool
+var replicateOptions ortfodb.ReplicateOptions
 
 var replicateCmd = &cobra.Command{
 	Use:   "replicate <from-filepath> <to-filepath>",
 	Short: "Replicate a database directory from a built database file.",
-	Long: heredoc.Doc(`Replicate a database from from-filepath to to-filepath. Note that to-filepath must be an empty directory.
+	Long: heredoc.Doc(`Replicate a database from from-filepath to to-filepath: write a description file for every work of the built database, so that building to-filepath gives the same database again. Note that to-filepath must be an empty directory.
 
-	WARNING: This command is still kind-of a WIP, it works but there's minimal logging and error handling.
+	Media files are copied back from the media directory (see media.at in the configuration file), or linked to with --media link.
+
+	Replicated description files are parsed again to check that they describe the same works. Works that could not be replicated exactly are reported, and the command exits with a non-zero status code.
 	`),
 	Example: "Example: ortfodb replicate ./database.json ./replicated-database/",
 	Args:    cobra.ExactArgs(2),
@@ -35,13 +38,18 @@ var replicateCmd = &cobra.Command{
 			handleError(err)
 		}
 
-		err = ctx.ReplicateAll(args[1], database)
-		handleError(err)
+		err = ctx.ReplicateAll(args[1], database, replicateOptions)
 		ortfodb.ReleaseBuildLock(args[0])
+		if err != nil {
+			handleError(err)
+		}
 	},
 }
 
 func init() {
 	replicateCmd.PersistentFlags().BoolVarP(&force, "no-verify", "n", false, "Don't try to validate the built database file before replicating")
+	replicateCmd.Flags().StringVar(&replicateOptions.Media, "media", ortfodb.ReplicateMediaCopy, "What to do with media files: copy, link or none")
+	replicateCmd.RegisterFlagCompletionFunc("media", cobra.FixedCompletions([]string{ortfodb.ReplicateMediaCopy, ortfodb.ReplicateMediaLink, ortfodb.ReplicateMediaNone}, cobra.ShellCompDirectiveNoFileComp))
+	replicateCmd.Flags().BoolVar(&replicateOptions.Verify, "check", true, "Parse replicated description files again to check that they describe the same works. Use --check=false to skip")
 	rootCmd.AddCommand(replicateCmd)
 }
@@ -22,6 +22,7 @@ var schemasCmd = &cobra.Command{
 		- database: the output database file
 		- tags: the tags repository file (tags.yaml)
 		- technologies: the technologies repository file (technologies.yaml)
+		- collections: the collections repository file (collections.yaml)
 		- exporter: the manifest file for an exporter
 	`),
 	ValidArgs: append(ortfodb.AvailableJSONSchemas, "list"),
@@ -40,6 +41,8 @@ var schemasCmd = &cobra.Command{
 			printSchema(ortfodb.TagsRepositoryJSONSchema())
 		case "technologies":
 			printSchema(ortfodb.TechnologiesRepositoryJSONSchema())
+		case "collections":
+			printSchema(ortfodb.CollectionsRepositoryJSONSchema())
 		case "exporter":
 			printSchema(ortfodb.ExporterManifestJSONSchema())
 		case "importer":
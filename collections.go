@@ -0,0 +1,280 @@
+package ortfodb
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	ll "github.com/gwennlbh/label-logger-go"
+	"gopkg.in/yaml.v2"
+)
+
+// Collection groups works into a series: a comic's chapters, an album's tracks, a client's projects, etc. See https://ortfo.org/db/collections for more information.
+type Collection struct {
+	// Unique identifier of the collection, used in the collections key of description files.
+	ID    string `yaml:"id" json:"id"`
+	Title string `yaml:"title" json:"title"`
+	// Description of the collection, as HTML.
+	Description HTMLString `yaml:"description,omitempty" json:"description"`
+	// Media to use as the collection's cover, written as "<work ID>/<path of the media, as written in that work's description file>". The media must be one of the collection's works' blocks.
+	CoverSource string `yaml:"cover,omitempty" json:"-"`
+	// Cover media, resolved from the cover source at build time.
+	Cover Media `yaml:"-" json:"cover"`
+	// IDs of the works of the collection, in order. Works that declare being part of the collection in their description file but are not listed here come after, from oldest to newest.
+	Works []string `yaml:"works,omitempty" json:"works"`
+	// Title, description and cover to use instead for a given language.
+	Translations map[string]CollectionTranslation `yaml:"translations,omitempty" json:"translations"`
+}
+
+// CollectionTranslation holds the parts of a collection that can differ depending on the language. Empty values mean that the collection's default one is used.
+type CollectionTranslation struct {
+	Title       string     `yaml:"title,omitempty" json:"title"`
+	Description HTMLString `yaml:"description,omitempty" json:"description"`
+	CoverSource string     `yaml:"cover,omitempty" json:"-"`
+	Cover       Media      `yaml:"-" json:"cover"`
+}
+
+func (c Collection) String() string {
+	return c.ID
+}
+
+// Localize returns the collection with its title, description and cover overriden by the ones of the given language, when defined.
+func (c Collection) Localize(language string) Collection {
+	translation, ok := c.Translations[language]
+	if !ok {
+		return c
+	}
+	if translation.Title != "" {
+		c.Title = translation.Title
+	}
+	if translation.Description != "" {
+		c.Description = translation.Description
+	}
+	if translation.CoverSource != "" {
+		c.CoverSource = translation.CoverSource
+		c.Cover = translation.Cover
+	}
+	return c
+}
+
+// Contains returns true if the given work is part of the collection.
+func (c Collection) Contains(workID string) bool {
+	return slices.Contains(c.Works, workID)
+}
+
+// Collections returns all collections of the database, sorted by ID.
+func (w Database) Collections() []Collection {
+	if len(w) == 0 {
+		return []Collection{}
+	}
+	collections := w.Meta().Collections
+	result := make([]Collection, 0, len(collections))
+	for _, id := range sortedKeys(collections) {
+		result = append(result, collections[id])
+	}
+	return result
+}
+
+// Collection returns the collection with the given ID.
+func (w Database) Collection(id string) (collection Collection, found bool) {
+	if len(w) == 0 {
+		return Collection{}, false
+	}
+	collection, found = w.Meta().Collections[id]
+	return
+}
+
+// CollectionsOf returns the collections the given work is part of, sorted by ID.
+func (w Database) CollectionsOf(workID string) []Collection {
+	collections := make([]Collection, 0)
+	for _, collection := range w.Collections() {
+		if collection.Contains(workID) {
+			collections = append(collections, collection)
+		}
+	}
+	return collections
+}
+
+// Previous returns the work that comes before the given one in the given collection.
+// found is false if the work is the first one of the collection, or is not part of it.
+func (w Database) Previous(collectionID string, workID string) (work Work, found bool) {
+	return w.neighbour(collectionID, workID, -1)
+}
+
+// Next returns the work that comes after the given one in the given collection.
+// found is false if the work is the last one of the collection, or is not part of it.
+func (w Database) Next(collectionID string, workID string) (work Work, found bool) {
+	return w.neighbour(collectionID, workID, +1)
+}
+
+func (w Database) neighbour(collectionID string, workID string, offset int) (work Work, found bool) {
+	collection, ok := w.Collection(collectionID)
+	if !ok {
+		return Work{}, false
+	}
+	for i, id := range collection.Works {
+		if id != workID {
+			continue
+		}
+		if i+offset < 0 || i+offset >= len(collection.Works) {
+			return Work{}, false
+		}
+		work, found = w[collection.Works[i+offset]]
+		return
+	}
+	return Work{}, false
+}
+
+// LoadCollectionsRepository loads the collections defined in the repository file set in the configuration.
+// Unlike tags and technologies, not having a collections repository is perfectly fine: collections are then defined by the works that declare being part of them.
+func (ctx *RunContext) LoadCollectionsRepository() ([]Collection, error) {
+	if len(ctx.CollectionsRepository) > 0 {
+		return ctx.CollectionsRepository, nil
+	}
+
+	var collections []Collection
+	if ctx.Config.Collections.Repository == "" {
+		return []Collection{}, nil
+	}
+	raw, err := readFileBytes(ctx.Config.Collections.Repository)
+	if err != nil {
+		return []Collection{}, fmt.Errorf("while reading %s: %w", ctx.Config.Collections.Repository, err)
+	}
+
+	err = yaml.Unmarshal(raw, &collections)
+	if err != nil {
+		return []Collection{}, fmt.Errorf("while decoding YAML: %w", err)
+	}
+
+	ctx.CollectionsRepository = collections
+	return collections, nil
+}
+
+// ResolveCollections computes the collections of the given works, from the collections repository and the collections declared in description files.
+// The works of each collection are put in order, and the covers are resolved.
+// An error is returned if a collection refers to a work or a cover that does not exist, if a work is listed twice in a collection, or if a work declares being part of a collection that is not in the repository (when there is one).
+func (ctx *RunContext) ResolveCollections(works Database) (map[string]Collection, error) {
+	repository, err := ctx.LoadCollectionsRepository()
+	if err != nil {
+		return nil, fmt.Errorf("while loading collections repository: %w", err)
+	}
+
+	collections := make(map[string]Collection)
+	for _, collection := range repository {
+		if collection.ID == "" {
+			return nil, fmt.Errorf("collection %q has no id", collection.Title)
+		}
+		if _, ok := collections[collection.ID]; ok {
+			return nil, fmt.Errorf("collection %s is defined twice in %s", collection.ID, ctx.Config.Collections.Repository)
+		}
+		// Translations get their covers resolved below, don't modify the cached repository's
+		collection.Translations = maps.Clone(collection.Translations)
+		collections[collection.ID] = collection
+	}
+
+	// Resolve the works listed in the repository, which might be referred to by one of their aliases
+	for id, collection := range collections {
+		listed := make([]string, 0, len(collection.Works))
+		for _, idOrAlias := range collection.Works {
+			work, found := works.FindWork(idOrAlias)
+			if !found {
+				return nil, fmt.Errorf("collection %s contains work %s, which does not exist", id, idOrAlias)
+			}
+			if slices.Contains(listed, work.ID) {
+				return nil, fmt.Errorf("collection %s contains work %s twice", id, work.ID)
+			}
+			listed = append(listed, work.ID)
+		}
+		collection.Works = listed
+		collections[id] = collection
+	}
+
+	// Add works that declare being part of a collection in their description file
+	declared := make(map[string][]Work)
+	for _, workID := range sortedKeys(works) {
+		work := works[workID]
+		for _, collectionID := range work.Metadata.Collections {
+			if _, ok := collections[collectionID]; !ok {
+				if ctx.Config.Collections.Repository != "" {
+					return nil, fmt.Errorf("work %s is part of collection %s, which is not defined in %s", workID, collectionID, ctx.Config.Collections.Repository)
+				}
+				collections[collectionID] = Collection{ID: collectionID, Title: collectionID}
+			}
+			if !collections[collectionID].Contains(workID) {
+				declared[collectionID] = append(declared[collectionID], work)
+			}
+		}
+	}
+	for collectionID, members := range declared {
+		collection := collections[collectionID]
+		sortWorksChronologically(members)
+		for _, work := range members {
+			collection.Works = append(collection.Works, work.ID)
+		}
+		collections[collectionID] = collection
+	}
+
+	// Resolve covers
+	for id, collection := range collections {
+		if len(collection.Works) == 0 {
+			ll.Warn("Collection %s has no works", id)
+		}
+
+		if collection.CoverSource != "" {
+			collection.Cover, err = resolveCollectionCover(works, collection, collection.CoverSource)
+			if err != nil {
+				return nil, fmt.Errorf("while resolving cover of collection %s: %w", id, err)
+			}
+		}
+		for language, translation := range collection.Translations {
+			if translation.CoverSource == "" {
+				continue
+			}
+			translation.Cover, err = resolveCollectionCover(works, collection, translation.CoverSource)
+			if err != nil {
+				return nil, fmt.Errorf("while resolving %s cover of collection %s: %w", language, id, err)
+			}
+			collection.Translations[language] = translation
+		}
+		collections[id] = collection
+	}
+
+	return collections, nil
+}
+
+func resolveCollectionCover(works Database, collection Collection, source string) (Media, error) {
+	workID, mediaPath, ok := strings.Cut(source, "/")
+	if !ok {
+		return Media{}, fmt.Errorf("cover %q should be written as <work ID>/<media path>", source)
+	}
+	work, found := works.FindWork(workID)
+	if !found {
+		return Media{}, fmt.Errorf("cover %q refers to work %s, which does not exist", source, workID)
+	}
+	if !collection.Contains(work.ID) {
+		return Media{}, fmt.Errorf("cover %q refers to work %s, which is not part of the collection", source, work.ID)
+	}
+	for _, language := range sortedKeys(work.Content) {
+		for _, block := range work.Content[language].Blocks {
+			if block.Type.IsMedia() && path.Clean(string(block.RelativeSource)) == path.Clean(mediaPath) {
+				return block.Media, nil
+			}
+		}
+	}
+	return Media{}, fmt.Errorf("cover %q is not a media of work %s", source, work.ID)
+}
+
+// sortWorksChronologically sorts works from oldest to newest, works with an unknown creation date last.
+func sortWorksChronologically(works []Work) {
+	sort.SliceStable(works, func(i, j int) bool {
+		iDate := works[i].Metadata.CreatedAt()
+		jDate := works[j].Metadata.CreatedAt()
+		if iDate.Equal(jDate) {
+			return works[i].ID < works[j].ID
+		}
+		return iDate.Before(jDate)
+	})
+}
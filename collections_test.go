@@ -0,0 +1,103 @@
+package ortfodb
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestResolveCollections(t *testing.T) {
+	works := Database{
+		"a": {ID: "a", Metadata: WorkMetadata{Started: Date{Year: 2021, Precision: DatePrecisionYear}}},
+		"b": {ID: "b", Metadata: WorkMetadata{Started: Date{Year: 2019, Precision: DatePrecisionYear}, Collections: []string{"comic"}}},
+		"c": {
+			ID:       "c",
+			Metadata: WorkMetadata{Aliases: []string{"old-c"}, Started: Date{Year: 2020, Precision: DatePrecisionYear}, Collections: []string{"comic"}},
+			Content: LocalizableContent{"default": {Blocks: []ContentBlock{
+				{ID: "m1", Type: "media", Media: Media{RelativeSource: "cover.png", DistSource: "c/cover.png"}},
+			}}},
+		},
+	}
+	tests := []struct {
+		name       string
+		repository []Collection
+		want       map[string][]string
+		wantCovers map[string]FilePathInsideMediaRoot
+		wantErr    bool
+	}{
+		{
+			name:       "declared works come after listed ones, oldest first",
+			repository: []Collection{{ID: "comic", Works: []string{"a"}}},
+			want:       map[string][]string{"comic": {"a", "b", "c"}},
+		},
+		{
+			name:       "aliases",
+			repository: []Collection{{ID: "comic", Works: []string{"old-c", "a"}}},
+			want:       map[string][]string{"comic": {"c", "a", "b"}},
+		},
+		{
+			name: "translated covers",
+			repository: []Collection{{ID: "comic", Translations: map[string]CollectionTranslation{
+				"fr": {Title: "BD", CoverSource: "c/cover.png"},
+			}}},
+			want:       map[string][]string{"comic": {"b", "c"}},
+			wantCovers: map[string]FilePathInsideMediaRoot{"fr": "c/cover.png"},
+		},
+		{
+			name:       "unknown work",
+			repository: []Collection{{ID: "comic", Works: []string{"d"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "work listed twice",
+			repository: []Collection{{ID: "comic", Works: []string{"a", "old-c", "c"}}},
+			wantErr:    true,
+		},
+		{
+			name:       "cover of a work outside of the collection",
+			repository: []Collection{{ID: "comic", CoverSource: "a/cover.png"}},
+			wantErr:    true,
+		},
+		{
+			name:       "collection missing from the repository",
+			repository: []Collection{{ID: "album"}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfiguration()
+			config.Collections.Repository = "collections.yaml"
+			ctx := &RunContext{Config: &config, CollectionsRepository: tt.repository}
+			repositoryBefore := make([]Collection, len(tt.repository))
+			for i, collection := range tt.repository {
+				repositoryBefore[i] = collection
+				repositoryBefore[i].Translations = maps.Clone(collection.Translations)
+			}
+
+			got, err := ctx.ResolveCollections(works)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveCollections() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, collection := range tt.repository {
+				if !reflect.DeepEqual(collection.Translations, repositoryBefore[i].Translations) {
+					t.Errorf("ResolveCollections() modified the repository's translations of %s: %+v", collection.ID, collection.Translations)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			for id, wantWorks := range tt.want {
+				if !reflect.DeepEqual(got[id].Works, wantWorks) {
+					t.Errorf("collection %s has works %v, want %v", id, got[id].Works, wantWorks)
+				}
+			}
+			for language, wantCover := range tt.wantCovers {
+				if cover := got["comic"].Translations[language].Cover.DistSource; cover != wantCover {
+					t.Errorf("%s cover is %q, want %q", language, cover, wantCover)
+				}
+			}
+		})
+	}
+}
@@ -53,6 +53,11 @@ type TechnologiesConfiguration struct {
 	Repository string
 }
 
+type CollectionsConfiguration struct {
+	// Path to file describing all collections.
+	Repository string
+}
+
 type MediaConfiguration struct {
 	// Path to the media directory.
 	At string
@@ -61,6 +66,58 @@ type MediaConfiguration struct {
 	AudioAnalysis bool `yaml:"audio analysis,omitempty"`
 }
 
+type MarkdownConfiguration struct {
+	// Markdown extensions to enable. Defaults to footnote, linkify, strikethrough, table, typographer, cjk and highlighting.
+	// Also available: definition list, task list, attributes and emoji.
+	Extensions []string `yaml:"extensions,omitempty"`
+
+	// Sanitization policy applied to the HTML generated from description files.
+	Sanitization SanitizationConfiguration `yaml:"sanitization,omitempty"`
+}
+
+type SanitizationConfiguration struct {
+	// Allow any HTML in description files, including <script> tags. Only use this if you trust every description file.
+	Disabled bool `yaml:"disabled,omitempty"`
+
+	// HTML tags to allow, in addition to the ones that can be generated from markdown.
+	AllowedTags []string `yaml:"allowed tags,omitempty"`
+
+	// HTML attributes to allow, in addition to the default ones. Maps tag names to attribute names. Use "*" as the tag name to allow attributes on every tag.
+	AllowedAttributes map[string][]string `yaml:"allowed attributes,omitempty"`
+}
+
+type LayoutsConfiguration struct {
+	// Names of the breakpoints that can be used in the layouts metadata, from the smallest screen to the largest.
+	// Works are given a layout for every breakpoint: breakpoints that a work does not declare a layout for use the layout of the closest smaller breakpoint.
+	Breakpoints []string `yaml:"breakpoints,omitempty"`
+}
+
+type LinksConfiguration struct {
+	// Template for the URL that links to other works are rewritten to. <work id> and <language> are replaced by the ID of the linked work and the language of the description. Defaults to "<work id>".
+	WorkURL string `yaml:"work url,omitempty"`
+
+	// Templates to use instead of work url for some languages. Maps language codes to templates.
+	LocalizedWorkURL map[string]string `yaml:"localized work url,omitempty"`
+
+	// What to do when a link points to a work or block that does not exist: "warning" (the default) to only warn about it, or "error" to fail the build.
+	// With "error", every link that looks like a work ID (see PatternWorkLink) and does not point to a file is checked. Otherwise, only links that name an existing work or alias are treated as links to other works.
+	Dangling DiagnosticSeverity `yaml:"dangling,omitempty"`
+}
+
+type DetectionConfiguration struct {
+	// Gitignore-style patterns of files and folders to never look into when detecting technologies and tags, in addition to node_modules, .venv, .git and the patterns of the works' .gitignore files.
+	// Negated patterns (starting with "!") can be used to look into folders that are ignored otherwise.
+	Ignore []string `yaml:"ignore,omitempty"`
+
+	// Detect technologies and tags of every work when building, and add the ones that their description files do not declare to their metadata. Also enabled by the --detect flag of the build command.
+	AtBuildTime bool `yaml:"at build time,omitempty"`
+}
+
+type AddConfiguration struct {
+	// Path to a directory of description templates, for ortfodb add --template. Templates are markdown files, named after the template with a .md extension.
+	Templates string `yaml:"templates,omitempty"`
+}
+
 // Configuration represents what the ortfodb.yaml configuration file describes.
 type Configuration struct {
 	// Signals whether the configuration was instanciated by DefaultConfiguration.
@@ -70,9 +127,15 @@ type Configuration struct {
 	MakeGifs            MakeGIFsConfiguration       `yaml:"make gifs,omitempty"`
 	MakeThumbnails      MakeThumbnailsConfiguration `yaml:"make thumbnails,omitempty"`
 	Media               MediaConfiguration          `yaml:"media,omitempty"`
+	Markdown            MarkdownConfiguration       `yaml:"markdown,omitempty"`
+	Layouts             LayoutsConfiguration        `yaml:"layouts,omitempty"`
 	ScatteredModeFolder string                      `yaml:"scattered mode folder"`
 	Tags                TagsConfiguration           `yaml:"tags,omitempty"`
 	Technologies        TechnologiesConfiguration   `yaml:"technologies,omitempty"`
+	Collections         CollectionsConfiguration    `yaml:"collections,omitempty"`
+	Links               LinksConfiguration          `yaml:"links,omitempty"`
+	Detection           DetectionConfiguration      `yaml:"detection,omitempty"`
+	Add                 AddConfiguration            `yaml:"add,omitempty"`
 
 	// Path to the directory containing all projects. Must be absolute.
 	ProjectsDirectory string `yaml:"projects at"`
@@ -151,12 +214,35 @@ func NewConfiguration(filename string) (Configuration, error) {
 		return Configuration{}, fmt.Errorf("while expanding home symbol for technologies repository at: %w", err)
 	}
 
+	config.Collections.Repository, err = homedir.Expand(config.Collections.Repository)
+	if err != nil {
+		return Configuration{}, fmt.Errorf("while expanding home symbol for collections repository at: %w", err)
+	}
+
+	config.Add.Templates, err = homedir.Expand(config.Add.Templates)
+	if err != nil {
+		return Configuration{}, fmt.Errorf("while expanding home symbol for add templates at: %w", err)
+	}
+
 	// Make sure the project directory exists, is a directory and is absolute.
 	err = checkProjectsDirectory(config)
 	if err != nil {
 		return Configuration{}, err
 	}
 
+	// Make sure the markdown extensions all exist
+	if _, err := NewMarkdownParser(config.Markdown.Extensions); err != nil {
+		return Configuration{}, fmt.Errorf("while checking markdown extensions: %w", err)
+	}
+
+	if config.Links.Dangling != "" && config.Links.Dangling != SeverityError && config.Links.Dangling != SeverityWarning {
+		return Configuration{}, fmt.Errorf("links.dangling must be %q or %q, not %q", SeverityError, SeverityWarning, config.Links.Dangling)
+	}
+
+	if stringInSlice(config.Layouts.Breakpoints, DefaultLayoutGrid) {
+		return Configuration{}, fmt.Errorf("layouts.breakpoints can't contain %q, which is reserved for the default layout", DefaultLayoutGrid)
+	}
+
 	// Set default value for ScatteredModeFolder
 	if config.ScatteredModeFolder == "" {
 		config.ScatteredModeFolder = ".ortfo"
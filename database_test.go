@@ -0,0 +1,68 @@
+package ortfodb
+
+import (
+	"reflect"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestDatabaseJSON(t *testing.T) {
+	repositories := DatabaseRepositories{
+		Collections:  map[string]Collection{"comic": {ID: "comic", Works: []string{"a"}}},
+		Tags:         []TagUsage{{Tag: Tag{Singular: "book", Plural: "books"}, WorksCount: 1, Children: []string{}}},
+		Technologies: []TechnologyUsage{{Technology: Technology{Slug: "go", Name: "Go"}, WorksCount: 0}},
+	}
+	tests := []struct {
+		name     string
+		database Database
+	}{
+		{
+			name:     "empty",
+			database: Database{},
+		},
+		{
+			name: "works and repositories",
+			database: Database{
+				"a": {ID: "a", Metadata: WorkMetadata{Tags: []string{"book"}, DatabaseMetadata: DatabaseMeta{DatabaseRepositories: repositories}}},
+				"b": {ID: "b", Metadata: WorkMetadata{DatabaseMetadata: DatabaseMeta{DatabaseRepositories: repositories}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := jsoniter.ConfigFastest.Marshal(tt.database)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			object := make(map[string]any)
+			if err := jsoniter.ConfigFastest.Unmarshal(data, &object); err != nil {
+				t.Fatalf("Unmarshal() into a map error = %v", err)
+			}
+			if _, ok := object[DatabaseMetaKey]; ok != (len(tt.database) > 0) {
+				t.Errorf("%s key present = %v, want %v", DatabaseMetaKey, ok, len(tt.database) > 0)
+			}
+			for id := range tt.database {
+				work, _ := object[id].(map[string]any)
+				metadata, _ := work["metadata"].(map[string]any)
+				if _, ok := metadata["databaseMetadata"].(map[string]any)["tags"]; ok {
+					t.Errorf("work %s has the repositories in its databaseMetadata", id)
+				}
+			}
+
+			var got Database
+			if err := jsoniter.ConfigFastest.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if len(got) != len(tt.database) {
+				t.Fatalf("Unmarshal() has %d works, want %d", len(got), len(tt.database))
+			}
+			for id, work := range tt.database {
+				if !reflect.DeepEqual(got[id].Metadata.DatabaseMetadata, work.Metadata.DatabaseMetadata) {
+					t.Errorf("work %s has database metadata %+v, want %+v", id, got[id].Metadata.DatabaseMetadata, work.Metadata.DatabaseMetadata)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package ortfodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/relvacode/iso8601"
+)
+
+// DatePrecision is how precisely a Date is known: "unknown", "year", "month" or "day". It is empty for dates that are not set.
+type DatePrecision string
+
+const (
+	DatePrecisionUnknown DatePrecision = "unknown"
+	DatePrecisionYear    DatePrecision = "year"
+	DatePrecisionMonth   DatePrecision = "month"
+	DatePrecisionDay     DatePrecision = "day"
+)
+
+// PatternPartialDate matches dates in the YYYY-MM-DD format, where the month and day can be omitted and any part can be replaced by question marks to signify that it is unknown.
+// Parts of a date are either fully known or fully unknown: "202?" is matched, but rejected by ParseDate.
+const PatternPartialDate = `^([\d?]{4})(?:-(\d{2}|\?\?)(?:-(\d{2}|\?\?))?)?$`
+
+// Date is a possibly partial date, as written in description files: "2021-05-29", "2021-05", "2021", "2021-??-??" or "????".
+// It can also be a date range, such as "2019/2021", in which case the Date is the start of the range, see ParseDateOrRange.
+// In JSON, dates are strings, as they are written in description files (see Date.String).
+type Date struct {
+	// Year, 0 if unknown.
+	Year int
+	// Month (1 to 12), 0 if unknown.
+	Month int
+	// Day of the month, 0 if unknown.
+	Day       int
+	Precision DatePrecision
+	// End of the range, nil if the date is not a range.
+	End *Date
+}
+
+// ParseDate parses a possibly partial date. See Date. An empty string gives a zero Date.
+// Full ISO 8601 date-times are also accepted, the time part is discarded.
+func ParseDate(raw string) (Date, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Date{}, nil
+	}
+
+	groups := regexp.MustCompile(PatternPartialDate).FindStringSubmatch(raw)
+	if groups == nil {
+		parsed, err := iso8601.ParseString(raw)
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, YYYY-MM or YYYY, with question marks for unknown parts", raw)
+		}
+		return Date{Year: parsed.Year(), Month: int(parsed.Month()), Day: parsed.Day(), Precision: DatePrecisionDay}, nil
+	}
+
+	// Only trailing parts can be unknown, since a Date stores nothing after the first unknown part
+	if strings.Contains(groups[1], "?") && groups[1] != "????" {
+		return Date{}, fmt.Errorf("invalid date %q: the year must be either fully known or written ????", raw)
+	}
+	if groups[1] == "????" && (strings.Trim(groups[2], "?") != "" || strings.Trim(groups[3], "?") != "") {
+		return Date{}, fmt.Errorf("invalid date %q: the month and day can't be known if the year is not", raw)
+	}
+	if groups[2] == "??" && strings.Trim(groups[3], "?") != "" {
+		return Date{}, fmt.Errorf("invalid date %q: the day can't be known if the month is not", raw)
+	}
+
+	date := Date{Precision: DatePrecisionUnknown}
+	if groups[1] == "????" {
+		return date, nil
+	}
+	date.Year, _ = strconv.Atoi(groups[1])
+	date.Precision = DatePrecisionYear
+
+	if groups[2] == "" || groups[2] == "??" {
+		return date, nil
+	}
+	date.Month, _ = strconv.Atoi(groups[2])
+	if date.Month < 1 || date.Month > 12 {
+		return Date{}, fmt.Errorf("invalid date %q: month must be between 01 and 12", raw)
+	}
+	date.Precision = DatePrecisionMonth
+
+	if groups[3] == "" || groups[3] == "??" {
+		return date, nil
+	}
+	date.Day, _ = strconv.Atoi(groups[3])
+	if date.Day < 1 || date.Day > daysInMonth(date.Year, date.Month) {
+		return Date{}, fmt.Errorf("invalid date %q: %s %d has %d days", raw, time.Month(date.Month), date.Year, daysInMonth(date.Year, date.Month))
+	}
+	date.Precision = DatePrecisionDay
+
+	return date, nil
+}
+
+// DateRangeSeparators separate the start and the end of date ranges: "2020-09/2021", "2020..2021-03-15" or "2019–2021" (with an en dash).
+var DateRangeSeparators = []string{"..", "/", "–"}
+
+// ParseDateRange parses two dates separated by one of the DateRangeSeparators, for example "2020-09/2021" or "2020..2021-03-15".
+// A single date is also accepted, in which case start and end are the same.
+func ParseDateRange(raw string) (start Date, end Date, err error) {
+	parts := []string{raw}
+	for _, separator := range DateRangeSeparators {
+		if strings.Contains(raw, separator) {
+			parts = strings.SplitN(raw, separator, 2)
+			break
+		}
+	}
+
+	if len(parts) == 2 && (strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "") {
+		return Date{}, Date{}, fmt.Errorf("invalid date range %q: both the start and the end must be given, use ???? for unknown ones", raw)
+	}
+
+	start, err = ParseDate(parts[0])
+	if err != nil {
+		return Date{}, Date{}, err
+	}
+	if len(parts) == 1 {
+		return start, start, nil
+	}
+
+	end, err = ParseDate(parts[1])
+	if err != nil {
+		return Date{}, Date{}, err
+	}
+	if start.Known() && end.Known() && end.Time().Before(start.Time()) {
+		return Date{}, Date{}, fmt.Errorf("invalid date range %q: ends before it starts", raw)
+	}
+	return start, end, nil
+}
+
+// ParseDateOrRange parses a date, or a date range (see ParseDateRange), in which case the start of the range is returned, with End set to the end of the range.
+func ParseDateOrRange(raw string) (Date, error) {
+	start, end, err := ParseDateRange(raw)
+	if err != nil {
+		return Date{}, err
+	}
+	if end != start {
+		start.End = &end
+	}
+	return start, nil
+}
+
+func daysInMonth(year int, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// IsZero returns true if the date is not set at all.
+func (d Date) IsZero() bool {
+	return d == Date{}
+}
+
+// Last returns the end of the range if the date is a range, or else the date itself.
+func (d Date) Last() Date {
+	if d.End != nil {
+		return *d.End
+	}
+	return d
+}
+
+// Known returns true if at least the year is known.
+func (d Date) Known() bool {
+	return !d.IsZero() && d.Precision != DatePrecisionUnknown
+}
+
+// String returns the date as it would be written in a description file: "2021-05-29", "2021-05", "2021" or "????", and "2019/2021" for ranges. Returns an empty string for a zero Date.
+func (d Date) String() string {
+	if d.End != nil {
+		start := d
+		start.End = nil
+		return start.String() + "/" + d.End.String()
+	}
+	switch d.Precision {
+	case DatePrecisionUnknown:
+		return "????"
+	case DatePrecisionYear:
+		return fmt.Sprintf("%04d", d.Year)
+	case DatePrecisionMonth:
+		return fmt.Sprintf("%04d-%02d", d.Year, d.Month)
+	case DatePrecisionDay:
+		return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+	}
+	return ""
+}
+
+// Time returns the first instant of the date, in UTC. For ranges, this is the first instant of the range. Unknown months and days are considered to be the first ones, and unknown (or unset) years are considered to be 9999.
+func (d Date) Time() time.Time {
+	year, month, day := d.Year, d.Month, d.Day
+	if !d.Known() {
+		year = 9999
+	}
+	return time.Date(year, time.Month(max(month, 1)), max(day, 1), 0, 0, 0, 0, time.UTC)
+}
+
+// UnmarshalText parses the date with ParseDateOrRange.
+func (d *Date) UnmarshalText(text []byte) error {
+	parsed, err := ParseDateOrRange(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON writes the date as a string, see Date.String.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON parses date strings. Invalid dates, from databases built with older versions, are loaded as zero dates.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*d, _ = ParseDateOrRange(raw)
+	return nil
+}
+
+// MarshalYAML writes the date as a string, as it is written in description files.
+func (d Date) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// JSONSchema describes dates as strings, see Date.MarshalJSON.
+func (Date) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "string",
+		Title:       "Date",
+		Description: "A possibly partial date or date range, as written in description files: 2021-05-29, 2021-05, 2021, 2021-??-??, ???? or 2019/2021. Empty if the date is not set.",
+	}
+}
@@ -0,0 +1,136 @@
+package ortfodb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Date
+		wantErr bool
+	}{
+		{raw: "", want: Date{}},
+		{raw: "2021-05-29", want: Date{Year: 2021, Month: 5, Day: 29, Precision: DatePrecisionDay}},
+		{raw: " 2021-05-29 ", want: Date{Year: 2021, Month: 5, Day: 29, Precision: DatePrecisionDay}},
+		{raw: "2021-05", want: Date{Year: 2021, Month: 5, Precision: DatePrecisionMonth}},
+		{raw: "2021", want: Date{Year: 2021, Precision: DatePrecisionYear}},
+		{raw: "2021-05-??", want: Date{Year: 2021, Month: 5, Precision: DatePrecisionMonth}},
+		{raw: "2021-??-??", want: Date{Year: 2021, Precision: DatePrecisionYear}},
+		{raw: "????", want: Date{Precision: DatePrecisionUnknown}},
+		{raw: "????-??-??", want: Date{Precision: DatePrecisionUnknown}},
+		{raw: "2021-05-29T12:30:00Z", want: Date{Year: 2021, Month: 5, Day: 29, Precision: DatePrecisionDay}},
+		{raw: "2024-02-29", want: Date{Year: 2024, Month: 2, Day: 29, Precision: DatePrecisionDay}},
+		{raw: "2021-02-29", wantErr: true},
+		{raw: "2021-13", wantErr: true},
+		{raw: "2021-00-10", wantErr: true},
+		{raw: "202?", wantErr: true},
+		{raw: "????-05", wantErr: true},
+		{raw: "2021-??-12", wantErr: true},
+		{raw: "29/05/2021", wantErr: true},
+		{raw: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseDate(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDate(%q) = %+v, want %+v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		raw       string
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{raw: "2021-05", wantStart: "2021-05", wantEnd: "2021-05"},
+		{raw: "2019/2021", wantStart: "2019", wantEnd: "2021"},
+		{raw: "2020-09..2021-03-15", wantStart: "2020-09", wantEnd: "2021-03-15"},
+		{raw: "2019–2021", wantStart: "2019", wantEnd: "2021"},
+		{raw: "????/2021", wantStart: "????", wantEnd: "2021"},
+		{raw: "2021/2021", wantStart: "2021", wantEnd: "2021"},
+		{raw: "2021/2019", wantErr: true},
+		{raw: "2021/2021-02-30", wantErr: true},
+		{raw: "2021/", wantErr: true},
+		{raw: "..2021", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			start, end, err := ParseDateRange(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDateRange(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if start.String() != tt.wantStart || end.String() != tt.wantEnd {
+				t.Errorf("ParseDateRange(%q) = %q, %q, want %q, %q", tt.raw, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	tests := []struct {
+		raw      string
+		wantJSON string
+	}{
+		{raw: "", wantJSON: `""`},
+		{raw: "2021-05-29", wantJSON: `"2021-05-29"`},
+		{raw: "2021-??", wantJSON: `"2021"`},
+		{raw: "????", wantJSON: `"????"`},
+		{raw: "2019–2021-03", wantJSON: `"2019/2021-03"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			date, err := ParseDateOrRange(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseDateOrRange(%q) error = %v", tt.raw, err)
+			}
+			data, err := json.Marshal(date)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("Marshal() = %s, want %s", data, tt.wantJSON)
+			}
+			var got Date
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got.String() != date.String() {
+				t.Errorf("Unmarshal(%s) = %q, want %q", data, got, date)
+			}
+		})
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{raw: "2021-05-29", want: time.Date(2021, 5, 29, 0, 0, 0, 0, time.UTC)},
+		{raw: "2021-05", want: time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{raw: "2021", want: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{raw: "????", want: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{raw: "2019/2021", want: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			date, err := ParseDateOrRange(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseDateOrRange(%q) error = %v", tt.raw, err)
+			}
+			if got := date.Time(); !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Time() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package ortfodb
 
 import (
-	"bytes"
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
@@ -12,20 +11,12 @@ import (
 	"time"
 	"unicode/utf8"
 
-	"gopkg.in/yaml.v2"
-	"mvdan.cc/xurls/v2"
-
 	"github.com/anaskhan96/soup"
 	ll "github.com/gwennlbh/label-logger-go"
 	"github.com/k3a/html2text"
 	"github.com/metal3d/go-slugify"
-	"github.com/mitchellh/mapstructure"
-	"github.com/relvacode/iso8601"
-	"github.com/yuin/goldmark"
-	goldmarkHighlight "github.com/yuin/goldmark-highlighting"
-	"github.com/yuin/goldmark/extension"
-	"github.com/yuin/goldmark/renderer/html"
 	"github.com/zyedidia/generic/mapset"
+	"golang.org/x/net/html"
 	// goldmarkFrontmatter "github.com/abhinav/goldmark-frontmatter"
 )
 
@@ -33,72 +24,29 @@ const (
 	PatternLanguageMarker         string = `^::\s+(.+)$`
 	PatternAbbreviationDefinition string = `^\s*\*\[([^\]]+)\]:\s+(.+)$`
 	PatternYAMLSeparator          string = `^\s*-{3,}\s*$`
+	PatternTOMLSeparator          string = `^\s*\+{3,}\s*$`
+	PatternBlockNameMarker        string = `^\s*\{#([\w-]+)\}\s*$`
+	PatternBlockIndexReference    string = `^[a-z]\d+$`
 	RuneLoop                      rune   = '~'
 	RuneAutoplay                  rune   = '>'
 	RuneHideControls              rune   = '='
 )
 
-var markdownParser = goldmark.New(
-	goldmark.WithExtensions(
-		extension.Footnote,
-		extension.NewLinkify(
-			extension.WithLinkifyURLRegexp(xurls.Relaxed()),
-		),
-		extension.Strikethrough,
-		extension.Table,
-		extension.Typographer,
-		extension.CJK,
-		goldmarkHighlight.NewHighlighting(),
-	),
-	goldmark.WithRendererOptions(
-		html.WithUnsafe(),
-	),
-)
-
-// ParseYAMLHeader parses the YAML header of a description markdown file and returns the rest of the content (all except the YAML header).
-func ParseYAMLHeader[Metadata interface{}](descriptionRaw string) (Metadata, string) {
-	var inYAMLHeader bool
-	var rawYAMLPart string
-	var markdownPart string
-	for _, line := range strings.Split(descriptionRaw, "\n") {
-		// Replace tabs with four spaces
-		for strings.HasPrefix(line, "\t") {
-			line = strings.Repeat(" ", 4) + strings.TrimPrefix(line, "\t")
-		}
-		// A YAML header separator is 3 or more dashes on a line (without anything else on the same line)
-		if regexp.MustCompile(PatternYAMLSeparator).MatchString(line) {
-			inYAMLHeader = !inYAMLHeader
-			continue
-		}
-		if inYAMLHeader {
-			rawYAMLPart += line + "\n"
-		} else {
-			markdownPart += line + "\n"
-		}
-	}
-	var parsedYAMLPart map[string]interface{}
-	yaml.Unmarshal([]byte(rawYAMLPart), &parsedYAMLPart)
-	if parsedYAMLPart == nil {
-		parsedYAMLPart = make(map[string]interface{})
-	}
-
-	var metadata Metadata
-	for key, value := range parsedYAMLPart {
-		if strings.Contains(key, " ") {
-			parsedYAMLPart[strings.ReplaceAll(key, " ", "_")] = value
-			delete(parsedYAMLPart, key)
-		}
-	}
-	mapstructure.Decode(parsedYAMLPart, &metadata)
-	return metadata, markdownPart
-}
-
 // ParseDescription parses the markdown string from a description.md file.
 // Media content blocks are left unanalyzed.
 // BuiltAt and DescriptionHash are also not set.
 func ParseDescription(ctx *RunContext, markdownRaw string, workID string) (Work, error) {
 	defer ll.TimeTrack(time.Now(), "ParseDescription", workID)
-	metadata, markdownRaw := ParseYAMLHeader[WorkMetadata](markdownRaw)
+	metadata, frontMatter, markdownRaw, err := ParseFrontMatter[WorkMetadata](markdownRaw)
+	if err != nil {
+		return Work{}, fmt.Errorf("while parsing front matter: %w", err)
+	}
+	for _, warning := range frontMatter.Warnings {
+		ll.Warn("%s: %s", workID, warning.Error())
+	}
+	if _, err := metadata.CreationDate(); err != nil {
+		return Work{}, fmt.Errorf("front matter, line %d: %w", frontMatter.KeyLine("created"), err)
+	}
 	// notLocalizedRaw: raw markdown before the first language marker
 	notLocalizedRaw, localizedRawBlocks := SplitOnLanguageMarkers(markdownRaw)
 	ll.Debug("split description into notLocalizedRaw: %#v and localizedRawBlocks: %#v", notLocalizedRaw, localizedRawBlocks)
@@ -110,6 +58,18 @@ func ParseDescription(ctx *RunContext, markdownRaw string, workID string) (Work,
 		// TODO: make this configurable
 		allLanguages = []string{"default"}
 	}
+	for language, overrides := range metadata.Localized {
+		// Same as top-level additional metadata, see ParseFrontMatter
+		for key, value := range overrides.AdditionalMetadata {
+			if strings.Contains(key, " ") {
+				overrides.AdditionalMetadata[strings.ReplaceAll(key, " ", "_")] = value
+				delete(overrides.AdditionalMetadata, key)
+			}
+		}
+		if !stringInSlice(allLanguages, language) {
+			ll.Warn("%s: front matter, line %d: metadata is localized in %q, but there is no content in that language", workID, frontMatter.KeyLine("localized"), language)
+		}
+	}
 	contentsPerLanguage := LocalizableContent{}
 	for _, language := range allLanguages {
 		// Unlocalized stuff appears the same in every language.
@@ -118,7 +78,7 @@ func ParseDescription(ctx *RunContext, markdownRaw string, workID string) (Work,
 			raw += localizedRawBlocks[language]
 		}
 
-		content := LocalizedContent{}
+		content := LocalizedContent{Metadata: metadata.Localize(language)}
 
 		var err error
 		content.Title, content.Blocks, content.Footnotes, content.Abbreviations, err = ctx.ParseSingleLanguageDescription(raw)
@@ -126,10 +86,42 @@ func ParseDescription(ctx *RunContext, markdownRaw string, workID string) (Work,
 			return Work{}, fmt.Errorf("while parsing %s description: %w", language, err)
 		}
 
-		content.Layout, err = ResolveLayout(metadata, language, content.Blocks)
+		content.Blocks = ctx.StabilizeBlockIDs(workID, language, content.Blocks)
+
+		grid, err := ResolveLayoutGrid(metadata, language, content.Blocks)
 		if err != nil {
 			return Work{}, fmt.Errorf("while resolving %s layout: %w", language, err)
 		}
+		content.Layout = grid.Layout()
+
+		content.Grids, err = ResolveLayoutGrids(metadata, language, content.Blocks, ctx.Config.Layouts.Breakpoints)
+		if err != nil {
+			return Work{}, fmt.Errorf("while resolving %s breakpoint layouts: %w", language, err)
+		}
+		if unused := unusedBlockNames(metadata, language, content.Blocks); len(unused) > 0 {
+			if localized {
+				return Work{}, fmt.Errorf("blocks named %s are not used in the %s layouts", strings.Join(unused, ", "), language)
+			}
+			return Work{}, fmt.Errorf("blocks named %s are not used in the layouts", strings.Join(unused, ", "))
+		}
+		content.Grids[DefaultLayoutGrid] = grid
+		declaredLayouts, _ := content.Metadata.AdditionalMetadata["layouts"].(map[string]interface{})
+		for _, breakpoint := range sortedKeys(declaredLayouts) {
+			if _, ok := declaredLayouts[breakpoint].([]interface{}); !ok {
+				continue
+			}
+			layoutBlocks := content.Grids[breakpoint].Layout().BlockIDs()
+			for _, block := range content.Blocks {
+				if stringInSlice(layoutBlocks, block.ID) {
+					continue
+				}
+				if localized {
+					ll.Warn("%s: %s layout in %s does not include %s", workID, breakpoint, language, blockRef(content.Blocks, block.ID))
+				} else {
+					ll.Warn("%s: %s layout does not include %s", workID, breakpoint, blockRef(content.Blocks, block.ID))
+				}
+			}
+		}
 
 		contentsPerLanguage[language] = content
 	}
@@ -163,6 +155,10 @@ type Link struct {
 type Work struct {
 	ID      string    `json:"id"`
 	BuiltAt time.Time `json:"builtAt"`
+	// Date at which the work was first built, i.e. when it appeared in the database.
+	FirstBuiltAt time.Time `json:"firstBuiltAt"`
+	// Date at which the work's description last changed, i.e. when its DescriptionHash was last different from the previous build's.
+	DescriptionChangedAt time.Time `json:"descriptionChangedAt"`
 
 	// Absolute path to the description.md file that describes this work.
 	Source string `json:"source"`
@@ -172,6 +168,14 @@ type Work struct {
 	Metadata WorkMetadata `json:"metadata"`
 	// Content (possibly localized): content blocks and layout.
 	Content LocalizableContent `json:"content"`
+	// Works that are related to this one, by type of relation: for example, Backlinks.SequelOf lists the works that are sequels of this one. Computed at build time from the other works' relations.
+	Backlinks WorkRelations `json:"backlinks"`
+	// Technologies and tags that were added to the metadata because they were detected, with the condition that was met for each of them. See DetectionConfiguration.AtBuildTime.
+	Detected DetectedMetadata `json:"detected"`
+	// Tags of the work, in any language, as they are in the tags repository. Tags that are not in it only have their singular name. See WorkMetadata.AllTags.
+	Tags []Tag `json:"tags"`
+	// Technologies the work was made with, as they are in the technologies repository. Technologies that are not in it only have their slug. See WorkMetadata.MadeWith.
+	Technologies []Technology `json:"technologies"`
 	// Was this work built and analyzed completely without errors?
 	Partial bool `json:"Partial"`
 }
@@ -187,7 +191,7 @@ func (w Work) ThumbnailBlock(language string) Media {
 			firstMatch = block.Media
 		}
 
-		if block.Media.RelativeSource == w.Metadata.Thumbnail {
+		if block.Media.RelativeSource == w.Metadata.Localize(language).Thumbnail {
 			return block.Media
 		}
 	}
@@ -237,45 +241,118 @@ func (thumbnails ThumbnailsMap) Closest(size int) FilePathInsideMediaRoot {
 
 type WorkMetadata struct {
 	Aliases            []string                      `json:"aliases" yaml:",omitempty"`
-	Finished           string                        `json:"finished" yaml:",omitempty"`
-	Started            string                        `json:"started"`
-	MadeWith           []string                      `json:"madeWith" yaml:"made with"`
-	Tags               []string                      `json:"tags"`
+	Finished           Date                          `json:"finished" yaml:",omitempty"`
+	Started            Date                          `json:"started" yaml:",omitempty"`
+	MadeWith           []string                      `json:"madeWith" yaml:"made with,omitempty"`
+	Tags               []string                      `json:"tags" yaml:",omitempty"`
 	Thumbnail          FilePathInsidePortfolioFolder `json:"thumbnail" yaml:",omitempty"`
 	TitleStyle         TitleStyle                    `json:"titleStyle" yaml:"title style,omitempty"`
 	Colors             ColorPalette                  `json:"colors" yaml:",omitempty"`
 	PageBackground     string                        `json:"pageBackground" yaml:"page background,omitempty"`
 	WIP                bool                          `json:"wip" yaml:",omitempty"`
 	Private            bool                          `json:"private" yaml:",omitempty"`
-	AdditionalMetadata map[string]interface{}        `mapstructure:",remain" json:"additionalMetadata" yaml:",omitempty"`
+	Collections        []string                      `json:"collections" yaml:",omitempty"`
+	Relations          WorkRelations                 `json:"relations" yaml:",omitempty"`
+	Localized          map[string]LocalizedMetadata  `json:"localized" yaml:",omitempty"`
+	AdditionalMetadata map[string]interface{}        `mapstructure:",remain" json:"additionalMetadata" yaml:",omitempty,inline"`
 	DatabaseMetadata   DatabaseMeta                  `json:"databaseMetadata" yaml:"-" `
 }
 
-func (m WorkMetadata) CreatedAt() time.Time {
-	var creationDate string
-	if m.AdditionalMetadata["created"] != nil {
-		creationDate = m.AdditionalMetadata["created"].(string)
-	} else if m.Finished != "" {
-		creationDate = m.Finished
-	} else {
-		creationDate = m.Started
+// CreationDate returns the date at which the work is considered to have been created: the "created" metadata if set, or else the finished date, or else the started date. The end of date ranges is used, except for the started date.
+// Returns an error if "created" is set to something that is not a valid date.
+func (m WorkMetadata) CreationDate() (Date, error) {
+	if created, ok := m.AdditionalMetadata["created"]; ok && created != nil {
+		var raw string
+		switch created := created.(type) {
+		case time.Time:
+			raw = created.Format(time.DateOnly)
+		default:
+			raw = fmt.Sprint(created)
+		}
+		_, end, err := ParseDateRange(raw)
+		if err != nil {
+			return Date{}, fmt.Errorf("while parsing created date: %w", err)
+		}
+		return end, nil
 	}
-	if creationDate == "" {
-		return time.Date(9999, time.January, 1, 0, 0, 0, 0, time.Local)
+	if !m.Finished.IsZero() {
+		return m.Finished.Last(), nil
 	}
-	parsedDate, err := parsePossiblyInterderminateDate(creationDate)
+	return m.Started, nil
+}
+
+// CreatedAt returns the time at which the work is considered to have been created, see CreationDate.
+// Works with an unknown or invalid creation date are considered to have been created in the year 9999.
+func (m WorkMetadata) CreatedAt() time.Time {
+	creationDate, err := m.CreationDate()
 	if err != nil {
-		panic(err)
+		return Date{}.Time()
 	}
-	return parsedDate
+	return creationDate.Time()
 }
 
-func parsePossiblyInterderminateDate(datestring string) (time.Time, error) {
-	return iso8601.ParseString(
-		strings.ReplaceAll(
-			strings.Replace(datestring, "????", "9999", 1), "?", "1",
-		),
-	)
+// LocalizedMetadata is the part of the metadata that can be overridden for a specific language, with the "localized" key of the front matter:
+//
+//	title style: left
+//	localized:
+//	  fr:
+//	    title style: right
+//	    subtitle: Un sous-titre
+type LocalizedMetadata struct {
+	Thumbnail          FilePathInsidePortfolioFolder `json:"thumbnail" yaml:",omitempty"`
+	TitleStyle         TitleStyle                    `json:"titleStyle" yaml:"title style,omitempty"`
+	PageBackground     string                        `json:"pageBackground" yaml:"page background,omitempty"`
+	Tags               []string                      `json:"tags" yaml:",omitempty"`
+	AdditionalMetadata map[string]interface{}        `mapstructure:",remain" json:"additionalMetadata" yaml:",omitempty,inline"`
+}
+
+// AllTags returns the work's tags, along with the tags it has in every language, without duplicates.
+func (m WorkMetadata) AllTags() []string {
+	tags := append([]string{}, m.Tags...)
+	for _, language := range sortedKeys(m.Localized) {
+		for _, tag := range m.Localized[language].Tags {
+			if !stringInSlice(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// Localize returns the metadata for the given language: values from the language's overrides, or else the work's values.
+// Additional metadata is merged key by key.
+func (m WorkMetadata) Localize(language string) LocalizedMetadata {
+	localized := LocalizedMetadata{
+		Thumbnail:          m.Thumbnail,
+		TitleStyle:         m.TitleStyle,
+		PageBackground:     m.PageBackground,
+		Tags:               m.Tags,
+		AdditionalMetadata: make(map[string]interface{}, len(m.AdditionalMetadata)),
+	}
+	for key, value := range m.AdditionalMetadata {
+		localized.AdditionalMetadata[key] = value
+	}
+
+	overrides, ok := m.Localized[language]
+	if !ok {
+		return localized
+	}
+	if overrides.Thumbnail != "" {
+		localized.Thumbnail = overrides.Thumbnail
+	}
+	if overrides.TitleStyle != "" {
+		localized.TitleStyle = overrides.TitleStyle
+	}
+	if overrides.PageBackground != "" {
+		localized.PageBackground = overrides.PageBackground
+	}
+	if overrides.Tags != nil {
+		localized.Tags = overrides.Tags
+	}
+	for key, value := range overrides.AdditionalMetadata {
+		localized.AdditionalMetadata[key] = value
+	}
+	return localized
 }
 
 type TitleStyle string
@@ -295,23 +372,34 @@ func (c LocalizableContent) Localize(lang string) LocalizedContent {
 }
 
 type LocalizedContent struct {
-	Layout        Layout         `json:"layout"`
-	Blocks        []ContentBlock `json:"blocks"`
-	Title         HTMLString     `json:"title"`
-	Footnotes     Footnotes      `json:"footnotes"`
-	Abbreviations Abbreviations  `json:"abbreviations"`
+	Metadata LocalizedMetadata `json:"metadata"`
+	Layout   Layout            `json:"layout"`
+	// Maps breakpoint names to their layout, with the layout of Layout under the DefaultLayoutGrid key.
+	Grids         map[string]LayoutGrid `json:"grids"`
+	Blocks        []ContentBlock        `json:"blocks"`
+	Title         HTMLString            `json:"title"`
+	Footnotes     Footnotes             `json:"footnotes"`
+	Abbreviations Abbreviations         `json:"abbreviations"`
 }
 
 type ContentBlock struct {
 	ID     string           `json:"id"`
 	Type   ContentBlockType `json:"type"`
 	Anchor string           `json:"anchor"`
+	Name   string           `json:"name"`
 	Index  int              `json:"index"`
+	// Links to other works found in the block. See ResolveWorkLinks.
+	References []WorkReference `json:"references"`
 	Media
 	Paragraph
 	Link
 }
 
+// isHeading returns true if the block is a paragraph block that is a heading.
+func (b ContentBlock) isHeading() bool {
+	return b.Type.IsParagraph() && regexpMatches(`^<h[2-6][\s>]`, string(b.Content))
+}
+
 func (b ContentBlock) AsMedia() Media {
 	if b.Type != "media" {
 		panic("ContentBlock is not a media")
@@ -462,7 +550,7 @@ func (b ContentBlock) generateID() string {
 // order contains an array of nanoids that represent the order of the content blocks as they are in the original file.
 func (ctx *RunContext) ParseSingleLanguageDescription(markdownRaw string) (title HTMLString, blocks []ContentBlock, footnotes Footnotes, abbreviations Abbreviations, err error) {
 	markdownRaw = HandleAltMediaEmbedSyntax(markdownRaw)
-	htmlRaw, err := MarkdownToHTML(markdownRaw)
+	htmlRaw, err := ctx.MarkdownToHTML(markdownRaw)
 	if err != nil {
 		err = fmt.Errorf("while converting markdown to HTML: %w", err)
 		return
@@ -490,12 +578,52 @@ func (ctx *RunContext) ParseSingleLanguageDescription(markdownRaw string) (title
 		}
 	}
 
+	// Name declared with a {#name} marker line, to give to the next block
+	pendingName := ""
 	for _, paragraph := range paragraphLike {
-		childrenCount := len(paragraph.Children())
+		children := paragraph.Children()
+		paragraphHTML := paragraph.HTML()
+		name := ""
+		if len(children) >= 1 && children[0].Pointer.Type == html.TextNode {
+			markerLine, rest, _ := strings.Cut(children[0].NodeValue, "\n")
+			if regexpMatches(PatternBlockNameMarker, markerLine) {
+				name = regexpGroups(PatternBlockNameMarker, markerLine)[1]
+				if regexpMatches(PatternBlockIndexReference, name) {
+					err = fmt.Errorf("block name %q looks like a block reference, choose another name", name)
+					return
+				}
+				if name == EmptyLayoutCell {
+					err = fmt.Errorf("block name %q is reserved for empty layout cells, choose another name", name)
+					return
+				}
+				if strings.TrimSpace(rest) == "" {
+					children = children[1:]
+				}
+				paragraphHTML = strings.Replace(paragraphHTML, markerLine+"\n", "", 1)
+			}
+		}
+		if name != "" && len(children) == 0 {
+			// Marker on its own: it names the next block
+			if pendingName != "" {
+				err = fmt.Errorf("block name %q is not followed by any block", pendingName)
+				return
+			}
+			pendingName = name
+			continue
+		}
+		if name == "" {
+			name = pendingName
+		} else if pendingName != "" {
+			err = fmt.Errorf("block name %q is not followed by any block", pendingName)
+			return
+		}
+
+		childrenCount := len(children)
 		firstChild := soup.Root{}
 		if childrenCount >= 1 {
-			firstChild = paragraph.Children()[0]
+			firstChild = children[0]
 		}
+		blocksCountBefore := len(blocks)
 		if childrenCount == 1 && firstChild.NodeValue == "img" {
 			// A media embed
 			alt, attributes := ExtractAttributesFromAlt(firstChild.Attrs()["alt"])
@@ -553,12 +681,32 @@ func (ctx *RunContext) ParseSingleLanguageDescription(markdownRaw string) (title
 				Type:   "paragraph",
 				Anchor: paragraph.Attrs()["id"],
 				Paragraph: Paragraph{
-					Content: HTMLString(paragraph.HTML()),
+					Content: HTMLString(paragraphHTML),
 				},
 			}
+			if block.isHeading() {
+				block.Name = block.Anchor
+			}
 			block.ID = block.generateID()
 			blocks = append(blocks, block)
 		}
+
+		if len(blocks) > blocksCountBefore {
+			pendingName = ""
+			if name != "" {
+				blocks[len(blocks)-1].Name = name
+				blocks[len(blocks)-1].Anchor = name
+			}
+			if blocks[len(blocks)-1].Name != "" {
+				blocks[len(blocks)-1].ID = blocks[len(blocks)-1].Name
+			}
+		} else {
+			pendingName = name
+		}
+	}
+	if pendingName != "" {
+		err = fmt.Errorf("block name %q is not followed by any block", pendingName)
+		return
 	}
 	if h1 := htmlTree.Find("h1"); h1.Error == nil {
 		title = innerHTML(h1)
@@ -570,20 +718,15 @@ func (ctx *RunContext) ParseSingleLanguageDescription(markdownRaw string) (title
 			}
 		}
 	}
-	seenBlockIDs := mapset.New[string]()
+	seenBlockNames := mapset.New[string]()
 	for i, block := range blocks {
-		if seenBlockIDs.Has(block.ID) {
-			switch block.Type {
-			case "paragraph":
-				err = fmt.Errorf("two different paragraphs have the exact same content")
-			case "media":
-				err = fmt.Errorf("two different media blocks have the exact same source")
-			case "link":
-				err = fmt.Errorf("two different links have the exact same URL")
+		if block.Name != "" {
+			if seenBlockNames.Has(block.Name) {
+				err = fmt.Errorf("two different blocks are named %q", block.Name)
+				return
 			}
-			return
+			seenBlockNames.Put(block.Name)
 		}
-		seenBlockIDs.Put(block.ID)
 		if block.Type != "paragraph" {
 			continue
 		}
@@ -594,6 +737,26 @@ func (ctx *RunContext) ParseSingleLanguageDescription(markdownRaw string) (title
 		blocks[i].Paragraph = ReplaceAbbreviations(block.Paragraph, abbreviations)
 	}
 
+	// Sanitize everything that ends up as HTML in the database
+	title = ctx.SanitizeHTML(title)
+	for name, footnote := range footnotes {
+		footnotes[name] = ctx.SanitizeHTML(footnote)
+	}
+	for i, block := range blocks {
+		switch block.Type {
+		case "paragraph":
+			blocks[i].Content = ctx.SanitizeHTML(block.Content)
+		case "media":
+			blocks[i].Caption = string(ctx.SanitizeHTML(HTMLString(block.Caption)))
+		case "link":
+			blocks[i].Text = ctx.SanitizeHTML(block.Text)
+			blocks[i].Link.Title = string(ctx.SanitizeHTML(HTMLString(block.Link.Title)))
+		}
+	}
+
+	// Identical blocks have the same generated ID
+	blocks = disambiguateBlockIDs(blocks, nil)
+
 	ll.Debug("Parsed description into blocks: %#v", blocks)
 	return
 }
@@ -671,22 +834,23 @@ func innerHTML(element soup.Root) HTMLString {
 	return HTMLString(innerHTML)
 }
 
-// MarkdownToHTML converts markdown markdownRaw into an HTML string.
-func MarkdownToHTML(markdownRaw string) (string, error) {
-	var buf bytes.Buffer
-	if err := markdownParser.Convert([]byte(markdownRaw), &buf); err != nil {
-		return "", err
-	}
-	return buf.String(), nil
-}
-
 // ReplaceAbbreviations processes the given Paragraph to replace abbreviations.
 func ReplaceAbbreviations(paragraph Paragraph, currentLanguageAbbreviations Abbreviations) Paragraph {
-	processed := paragraph.Content
-	for name, definition := range currentLanguageAbbreviations {
-		var replacePattern = regexp.MustCompile(`\b` + name + `\b`)
-		processed = HTMLString(replacePattern.ReplaceAllString(string(paragraph.Content), "<abbr title=\""+definition+"\">"+name+"</abbr>"))
+	processed := string(paragraph.Content)
+	for _, name := range sortedKeys(currentLanguageAbbreviations) {
+		replacePattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		replacement := "<abbr title=\"" + currentLanguageAbbreviations[name] + "\">" + name + "</abbr>"
+		// Don't replace inside of tags, such as in the title of abbreviations replaced before
+		parts := regexp.MustCompile(`<[^>]*>`).Split(processed, -1)
+		tags := regexp.MustCompile(`<[^>]*>`).FindAllString(processed, -1)
+		processed = ""
+		for i, part := range parts {
+			processed += replacePattern.ReplaceAllLiteralString(part, replacement)
+			if i < len(tags) {
+				processed += tags[i]
+			}
+		}
 	}
 
-	return Paragraph{Content: processed}
+	return Paragraph{Content: HTMLString(processed)}
 }
@@ -0,0 +1,484 @@
+package ortfodb
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	ll "github.com/gwennlbh/label-logger-go"
+)
+
+// Files larger than this are not read when detecting technologies and tags.
+const maxSearchedFileSize = 1 << 20
+
+// Detection explains why a tag or technology was detected as being used by a work.
+type Detection struct {
+	// Condition that was met, as written in the repository: a file pattern, a search condition, or "made with" followed by a technology.
+	Condition string `json:"condition"`
+	// Path, relative to the work's folder, of the file that met the condition. Empty for "made with" conditions.
+	Path string `json:"path"`
+}
+
+func (d Detection) String() string {
+	if d.Path == "" {
+		return d.Condition
+	}
+	return fmt.Sprintf("%s (in %s)", d.Condition, d.Path)
+}
+
+// DetectedTechnology is a technology that was detected as being used by a work, along with why it was.
+type DetectedTechnology struct {
+	Technology
+	Detection Detection
+}
+
+func (t DetectedTechnology) String() string {
+	return t.Technology.String()
+}
+
+// DetectedTag is a tag that was detected as being used by a work, along with why it was.
+type DetectedTag struct {
+	Tag
+	Detection Detection
+}
+
+func (t DetectedTag) String() string {
+	return t.Tag.String()
+}
+
+// ParseSearchCondition compiles a search condition of a tag's detect conditions into a regular expression.
+// Conditions written between slashes, optionally followed by flags (i, m, s or U), are regular expressions: "/hello,? world/i".
+// Other conditions are keywords, matched case-insensitively as whole words.
+func ParseSearchCondition(condition string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(condition, "/") {
+		end := strings.LastIndex(condition, "/")
+		if end > 0 {
+			expression, flags := condition[1:end], condition[end+1:]
+			if strings.Trim(flags, "imsU") != "" {
+				return nil, fmt.Errorf("invalid flags %q in search condition %s, only i, m, s and U are supported", flags, condition)
+			}
+			if flags != "" {
+				expression = "(?" + flags + ")" + expression
+			}
+			pattern, err := regexp.Compile(expression)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression in search condition %s: %w", condition, err)
+			}
+			return pattern, nil
+		}
+	}
+
+	keyword := regexp.QuoteMeta(strings.TrimSpace(condition))
+	if keyword == "" {
+		return nil, fmt.Errorf("empty search condition")
+	}
+	// Only require word boundaries where the keyword has word characters, so that keywords like "c++" still match
+	if regexp.MustCompile(`^\w`).MatchString(keyword) {
+		keyword = `\b` + keyword
+	}
+	if regexp.MustCompile(`\w$`).MatchString(keyword) {
+		keyword = keyword + `\b`
+	}
+	return regexp.Compile("(?i)" + keyword)
+}
+
+// defaultDetectionIgnores lists gitignore-style patterns of folders that are never looked into when detecting technologies and tags, unless negated by the configuration's detection.ignore.
+var defaultDetectionIgnores = []string{"node_modules/", ".venv/", ".git/"}
+
+// workScan lists the files of a work's folder, for detecting technologies and tags. See RunContext.scanWork.
+type workScan struct {
+	// Absolute path to the work's folder.
+	root string
+	// Files and folders of the work's folder that are not ignored, relative to it, in walk order.
+	entries []scannedEntry
+	// Manifests found in the work's folder, in walk order.
+	manifests []Manifest
+
+	mu       sync.Mutex
+	contents map[string]*string
+}
+
+type scannedEntry struct {
+	// Slash-separated path, relative to the work's folder.
+	path  string
+	isDir bool
+}
+
+func (e scannedEntry) fragments() []string {
+	return strings.Split(e.path, "/")
+}
+
+// scanWork walks the folder of the given work once, skipping ignored files (see DetectionConfiguration), and parses the manifests it contains.
+// Scans are cached for the lifetime of the run context.
+func (ctx *RunContext) scanWork(workId string) (*workScan, error) {
+	ctx.mu.Lock()
+	scan, ok := ctx.workScans[workId]
+	ctx.mu.Unlock()
+	if ok {
+		return scan, nil
+	}
+
+	scan = &workScan{root: filepath.Join(ctx.DatabaseDirectory, workId), contents: make(map[string]*string)}
+	configuredIgnores := parsePatterns(append(append([]string{}, defaultDetectionIgnores...), ctx.Config.Detection.Ignore...))
+	gitignores := make([]gitignore.Pattern, 0)
+
+	err := fs.WalkDir(os.DirFS(scan.root), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		entry := scannedEntry{path: path, isDir: d.IsDir()}
+
+		if path != "." {
+			// The configuration takes precedence over .gitignore files
+			result := lastMatch(configuredIgnores, entry.fragments(), entry.isDir)
+			if result == gitignore.NoMatch {
+				result = lastMatch(gitignores, entry.fragments(), entry.isDir)
+			}
+			if result == gitignore.Exclude {
+				if entry.isDir {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			scan.entries = append(scan.entries, entry)
+		}
+
+		if entry.isDir {
+			patterns, err := readGitignore(scan.root, path)
+			if err != nil {
+				return err
+			}
+			gitignores = append(gitignores, patterns...)
+			return nil
+		}
+
+		if IsManifest(path) {
+			contents, ok, err := scan.read(path)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return nil
+			}
+			manifest, err := ParseManifest(path, []byte(contents))
+			if err != nil {
+				ll.WarnDisplay("could not read manifest %s of %s", err, path, workId)
+				return nil
+			}
+			scan.manifests = append(scan.manifests, manifest)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("while scanning files of %s: %w", workId, err)
+	}
+
+	ll.Debug("Scanned %s: %d files and folders, manifests %v", workId, len(scan.entries), scan.manifests)
+	ctx.mu.Lock()
+	if ctx.workScans == nil {
+		ctx.workScans = make(map[string]*workScan)
+	}
+	ctx.workScans[workId] = scan
+	ctx.mu.Unlock()
+	return scan, nil
+}
+
+// readGitignore returns the patterns of the .gitignore file in directory (relative to root), if there is one.
+func readGitignore(root string, directory string) ([]gitignore.Pattern, error) {
+	var domain []string
+	if directory != "." {
+		domain = strings.Split(directory, "/")
+	}
+	patterns := make([]gitignore.Pattern, 0)
+	contents, err := os.ReadFile(filepath.Join(root, directory, ".gitignore"))
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return patterns, fmt.Errorf("while reading .gitignore of %s: %w", directory, err)
+	}
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns, nil
+}
+
+// lastMatch returns the result of the last of the patterns that matches path, like git does.
+func lastMatch(patterns []gitignore.Pattern, path []string, isDir bool) gitignore.MatchResult {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		if result := patterns[i].Match(path, isDir); result != gitignore.NoMatch {
+			return result
+		}
+	}
+	return gitignore.NoMatch
+}
+
+// read returns the contents of the file at path, relative to the work's folder. Contents are cached.
+// ok is false if the file does not exist or is larger than maxSearchedFileSize.
+func (s *workScan) read(path string) (contents string, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, found := s.contents[path]; found {
+		if cached == nil {
+			return "", false, nil
+		}
+		return *cached, true, nil
+	}
+
+	info, err := os.Stat(filepath.Join(s.root, path))
+	if os.IsNotExist(err) || (err == nil && (info.IsDir() || info.Size() > maxSearchedFileSize)) {
+		ll.Debug("Not reading %s in %s: does not exist or too large", path, s.root)
+		s.contents[path] = nil
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	contents, err = readFile(filepath.Join(s.root, path))
+	if err != nil {
+		return "", false, fmt.Errorf("while reading %s: %w", path, err)
+	}
+	s.contents[path] = &contents
+	return contents, true, nil
+}
+
+// match returns the first file or folder that matches the given gitignore-style patterns.
+func (s *workScan) match(patterns []string) (Detection, bool) {
+	if len(patterns) == 0 {
+		return Detection{}, false
+	}
+	parsed := parsePatterns(patterns)
+	for _, entry := range s.entries {
+		if i, matched := matchingPattern(parsed, entry); matched {
+			return Detection{Condition: patterns[i], Path: entry.path}, true
+		}
+	}
+	return Detection{}, false
+}
+
+func parsePatterns(patterns []string) []gitignore.Pattern {
+	parsed := make([]gitignore.Pattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		parsed = append(parsed, gitignore.ParsePattern(pattern, nil))
+	}
+	return parsed
+}
+
+// matchingPattern returns the index of the pattern that matches entry.
+// Like in .gitignore files, later patterns take precedence, so negated patterns can exclude files matched by earlier ones.
+func matchingPattern(patterns []gitignore.Pattern, entry scannedEntry) (int, bool) {
+	for i := len(patterns) - 1; i >= 0; i-- {
+		switch patterns[i].Match(entry.fragments(), entry.isDir) {
+		case gitignore.Exclude:
+			return i, true
+		case gitignore.Include:
+			return i, false
+		}
+	}
+	return -1, false
+}
+
+// searchedFiles returns the contents of the files that the search conditions of tags are matched against: the work's description file, and the files of the work's folder that match one of the patterns (gitignore-style).
+// When there are no patterns, README files at the root of the work's folder are searched.
+// Keys are paths relative to the work's folder.
+func (ctx *RunContext) searchedFiles(workId string, patterns []string) (map[string]string, error) {
+	scan, err := ctx.scanWork(workId)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+
+	// When detecting tags for a new work, the description file does not exist yet, and read skips it
+	descriptionPath, _ := filepath.Rel(scan.root, ctx.DescriptionFilename(ctx.DatabaseDirectory, workId))
+	paths := []string{filepath.ToSlash(descriptionPath)}
+	parsed := parsePatterns(patterns)
+
+	for _, entry := range scan.entries {
+		if entry.isDir {
+			continue
+		}
+		if len(patterns) == 0 {
+			if !strings.Contains(entry.path, "/") && strings.HasPrefix(strings.ToLower(entry.path), "readme") {
+				paths = append(paths, entry.path)
+			}
+		} else if _, matched := matchingPattern(parsed, entry); matched {
+			paths = append(paths, entry.path)
+		}
+	}
+
+	for _, path := range paths {
+		contents, ok, err := scan.read(path)
+		if err != nil {
+			return files, err
+		}
+		if ok {
+			files[path] = contents
+		}
+	}
+	return files, nil
+}
+
+// detectSearch returns the first of the tag's search conditions that is found in the searched files of the work.
+func (t Tag) detectSearch(ctx *RunContext, workId string) (detection Detection, matched bool, err error) {
+	if len(t.DetectConditions.Search) == 0 {
+		return Detection{}, false, nil
+	}
+
+	files, err := ctx.searchedFiles(workId, t.DetectConditions.SearchIn)
+	if err != nil {
+		return Detection{}, false, fmt.Errorf("while collecting files to search: %w", err)
+	}
+
+	for _, condition := range t.DetectConditions.Search {
+		pattern, err := ParseSearchCondition(condition)
+		if err != nil {
+			return Detection{}, false, err
+		}
+		for _, path := range sortedKeys(files) {
+			if pattern.MatchString(files[path]) {
+				ll.Debug("Auto-detected %s in %s: search condition %q met in %s", t, workId, condition, path)
+				return Detection{Condition: condition, Path: path}, true, nil
+			}
+		}
+	}
+	return Detection{}, false, nil
+}
+
+// DetectedMetadata records which technologies and tags of a work's metadata were added because they were detected, and why.
+// Maps technology slugs and tag singular names to the condition that was met.
+type DetectedMetadata struct {
+	MadeWith map[string]Detection `json:"madeWith"`
+	Tags     map[string]Detection `json:"tags"`
+}
+
+// DetectMetadata detects the technologies and tags of the given work, see DetectTechnologies and DetectTags.
+// Tags are detected using both the technologies declared in metadata and the detected ones.
+func (ctx *RunContext) DetectMetadata(workId string, metadata WorkMetadata) ([]DetectedTechnology, []DetectedTag, error) {
+	technologies, err := ctx.DetectTechnologies(workId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while detecting technologies: %w", err)
+	}
+
+	madeWith := make([]Technology, 0, len(metadata.MadeWith)+len(technologies))
+	for _, name := range metadata.MadeWith {
+		if tech, ok := ctx.FindTechnology(name); ok {
+			madeWith = append(madeWith, tech)
+		}
+	}
+	for _, tech := range technologies {
+		madeWith = append(madeWith, tech.Technology)
+	}
+
+	tags, err := ctx.DetectTags(workId, madeWith)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while detecting tags: %w", err)
+	}
+	return technologies, tags, nil
+}
+
+// DeclaresTechnology returns true if the metadata declares the given technology in made with.
+func (metadata WorkMetadata) DeclaresTechnology(tech Technology) bool {
+	return slices.ContainsFunc(metadata.MadeWith, tech.ReferredToBy)
+}
+
+// DeclaresTag returns true if the metadata declares the given tag, or one of its descendants (which makes the work tagged with it already).
+func (ctx *RunContext) DeclaresTag(metadata WorkMetadata, tag Tag) bool {
+	return ctx.tagsInclude(metadata.Tags, tag)
+}
+
+// tagsInclude returns true if the given tag names include the given tag, or one of its descendants.
+func (ctx *RunContext) tagsInclude(tags []string, tag Tag) bool {
+	for _, candidate := range append([]Tag{tag}, ctx.TagDescendants(tag)...) {
+		if slices.ContainsFunc(tags, candidate.ReferredToBy) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectInWorks adds the technologies and tags detected in every work to their metadata, and records them in their Detected field.
+// Detected tags are also added to the metadata of each language's content.
+// Technologies and tags declared in description files are kept as-is: detected ones are only added if they are not declared already.
+// Entries added by a previous build are forgotten first, so that they are removed if they are not detected anymore.
+func (ctx *RunContext) DetectInWorks(works Database) error {
+	if ctx.Config.Tags.Repository != "" {
+		if _, err := ctx.LoadTagsRepository(); err != nil {
+			return fmt.Errorf("while loading tags repository: %w", err)
+		}
+	}
+	if ctx.Config.Technologies.Repository != "" {
+		if _, err := ctx.LoadTechnologiesRepository(); err != nil {
+			return fmt.Errorf("while loading technologies repository: %w", err)
+		}
+	}
+
+	ForgetDetected(works)
+	for _, workID := range sortedKeys(works) {
+		work := works[workID]
+
+		technologies, tags, err := ctx.DetectMetadata(workID, work.Metadata)
+		if err != nil {
+			return fmt.Errorf("while detecting technologies and tags of %s: %w", workID, err)
+		}
+		for _, tech := range technologies {
+			if !work.Metadata.DeclaresTechnology(tech.Technology) {
+				work.Metadata.MadeWith = append(work.Metadata.MadeWith, tech.Slug)
+				work.Detected.MadeWith[tech.Slug] = tech.Detection
+			}
+		}
+		for _, tag := range tags {
+			if !ctx.DeclaresTag(work.Metadata, tag.Tag) {
+				work.Metadata.Tags = append(work.Metadata.Tags, tag.Singular)
+				work.Detected.Tags[tag.Singular] = tag.Detection
+			}
+		}
+		for language, content := range work.Content {
+			// Cloned, since the slice may be shared with the work's metadata
+			contentTags := slices.Clone(content.Metadata.Tags)
+			for _, tag := range tags {
+				if _, detected := work.Detected.Tags[tag.Singular]; detected && !ctx.tagsInclude(contentTags, tag.Tag) {
+					contentTags = append(contentTags, tag.Singular)
+				}
+			}
+			content.Metadata.Tags = contentTags
+			work.Content[language] = content
+		}
+		ll.Debug("Detected in %s: %v", workID, work.Detected)
+		works[workID] = work
+	}
+	return nil
+}
+
+// ForgetDetected removes the technologies and tags that were added to the metadata of works and of their contents by DetectInWorks, for example by a previous build.
+// Tags that a language declares in its localized metadata are kept in its content.
+func ForgetDetected(works Database) {
+	for workID, work := range works {
+		work.Metadata.MadeWith = slices.DeleteFunc(work.Metadata.MadeWith, func(slug string) bool {
+			_, detected := work.Detected.MadeWith[slug]
+			return detected
+		})
+		work.Metadata.Tags = slices.DeleteFunc(work.Metadata.Tags, func(name string) bool {
+			_, detected := work.Detected.Tags[name]
+			return detected
+		})
+		for language, content := range work.Content {
+			content.Metadata.Tags = slices.DeleteFunc(slices.Clone(content.Metadata.Tags), func(name string) bool {
+				_, detected := work.Detected.Tags[name]
+				return detected && !stringInSlice(work.Metadata.Localized[language].Tags, name)
+			})
+			work.Content[language] = content
+		}
+		work.Detected = DetectedMetadata{MadeWith: make(map[string]Detection), Tags: make(map[string]Detection)}
+		works[workID] = work
+	}
+}
@@ -0,0 +1,367 @@
+package ortfodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DatabaseDiff is what changed between two builds of a database. See DiffDatabases.
+type DatabaseDiff struct {
+	// IDs of works that are only in the new database.
+	Added []string `json:"added"`
+	// IDs of works that are only in the old database.
+	Removed []string `json:"removed"`
+	// Works that are in both databases but changed.
+	Changed []WorkDiff `json:"changed"`
+}
+
+// WorkDiff is what changed in a work between two builds.
+type WorkDiff struct {
+	ID string `json:"id"`
+	// Changed metadata fields, with their JSON name. Fields of objects are separated by dots, for example "colors.primary".
+	Metadata []FieldChange `json:"metadata"`
+	// Languages the work is only in in the new database.
+	AddedLanguages []string `json:"addedLanguages"`
+	// Languages the work is only in in the old database.
+	RemovedLanguages []string `json:"removedLanguages"`
+	// Changes in the content of each language the work is in in both databases.
+	Content []ContentDiff `json:"content"`
+}
+
+// FieldChange is a value that changed between two builds.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// ContentDiff is what changed in the content of a work in a language between two builds.
+type ContentDiff struct {
+	Language string `json:"language"`
+	// Changes to the title, footnotes and abbreviations.
+	Fields []FieldChange `json:"fields"`
+	// Blocks that were added, removed or modified. Blocks are matched by ID.
+	Blocks []BlockChange `json:"blocks"`
+	// Whether the layout, or the layout of any breakpoint, changed.
+	LayoutChanged bool `json:"layoutChanged"`
+	// Media files that changed, or whose thumbnails were regenerated.
+	Media []MediaChange `json:"media"`
+}
+
+// BlockChangeKind is how a block changed between two builds.
+type BlockChangeKind string
+
+const (
+	BlockAdded    BlockChangeKind = "added"
+	BlockRemoved  BlockChangeKind = "removed"
+	BlockModified BlockChangeKind = "modified"
+)
+
+// BlockChange is a content block that was added, removed or modified between two builds.
+type BlockChange struct {
+	Kind BlockChangeKind  `json:"kind"`
+	ID   string           `json:"id"`
+	Type ContentBlockType `json:"type"`
+	// Short plain-text summary of the block (of its new version if it was modified), to show to humans.
+	Summary string `json:"summary"`
+}
+
+// MediaChange is a media block whose file changed, or whose thumbnails were regenerated, between two builds.
+type MediaChange struct {
+	// ID of the media block.
+	Block  string                        `json:"block"`
+	Source FilePathInsidePortfolioFolder `json:"source"`
+	// Old and new hash of the file, if it changed.
+	OldHash string `json:"oldHash,omitempty"`
+	NewHash string `json:"newHash,omitempty"`
+	// Whether thumbnails were built again.
+	ThumbnailsRegenerated bool `json:"thumbnailsRegenerated"`
+}
+
+// DiffDatabases compares two builds of a database, work by work.
+// What does not depend on the description files, such as build times and the database metadata (which is included in every work), is ignored.
+func DiffDatabases(old Database, new Database) DatabaseDiff {
+	diff := DatabaseDiff{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Changed: make([]WorkDiff, 0),
+	}
+	for _, id := range sortedKeys(old) {
+		if _, ok := new[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	for _, id := range sortedKeys(new) {
+		if _, ok := old[id]; !ok {
+			diff.Added = append(diff.Added, id)
+			continue
+		}
+		if workDiff := diffWorks(old[id], new[id]); workDiff.HasChanges() {
+			diff.Changed = append(diff.Changed, workDiff)
+		}
+	}
+	return diff
+}
+
+// HasChanges returns true if anything changed between the two builds.
+func (diff DatabaseDiff) HasChanges() bool {
+	return len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0
+}
+
+// HasChanges returns true if anything changed in the work between the two builds.
+func (diff WorkDiff) HasChanges() bool {
+	return len(diff.Metadata) > 0 || len(diff.AddedLanguages) > 0 || len(diff.RemovedLanguages) > 0 || len(diff.Content) > 0
+}
+
+// HasChanges returns true if anything changed in the content between the two builds.
+func (diff ContentDiff) HasChanges() bool {
+	return len(diff.Fields) > 0 || len(diff.Blocks) > 0 || diff.LayoutChanged || len(diff.Media) > 0
+}
+
+func diffWorks(old Work, new Work) WorkDiff {
+	diff := WorkDiff{
+		ID:               new.ID,
+		Metadata:         make([]FieldChange, 0),
+		AddedLanguages:   make([]string, 0),
+		RemovedLanguages: make([]string, 0),
+		Content:          make([]ContentDiff, 0),
+	}
+
+	oldMetadata, newMetadata := old.Metadata, new.Metadata
+	oldMetadata.DatabaseMetadata, newMetadata.DatabaseMetadata = DatabaseMeta{}, DatabaseMeta{}
+	diff.Metadata = diffFields("", jsonValue(oldMetadata), jsonValue(newMetadata))
+
+	for _, language := range sortedKeys(old.Content) {
+		if _, ok := new.Content[language]; !ok {
+			diff.RemovedLanguages = append(diff.RemovedLanguages, language)
+		}
+	}
+	for _, language := range sortedKeys(new.Content) {
+		if _, ok := old.Content[language]; !ok {
+			diff.AddedLanguages = append(diff.AddedLanguages, language)
+			continue
+		}
+		if contentDiff := diffContents(language, old.Content[language], new.Content[language]); contentDiff.HasChanges() {
+			diff.Content = append(diff.Content, contentDiff)
+		}
+	}
+	return diff
+}
+
+func diffContents(language string, old LocalizedContent, new LocalizedContent) ContentDiff {
+	diff := ContentDiff{
+		Language: language,
+		Fields:   make([]FieldChange, 0),
+		Blocks:   make([]BlockChange, 0),
+		Media:    make([]MediaChange, 0),
+	}
+
+	for _, field := range []struct {
+		name     string
+		old, new any
+	}{
+		{"title", old.Title, new.Title},
+		{"footnotes", old.Footnotes, new.Footnotes},
+		{"abbreviations", old.Abbreviations, new.Abbreviations},
+	} {
+		diff.Fields = append(diff.Fields, diffFields(field.name, jsonValue(field.old), jsonValue(field.new))...)
+	}
+
+	oldBlocks := make(map[string]ContentBlock, len(old.Blocks))
+	for _, block := range old.Blocks {
+		oldBlocks[block.ID] = block
+	}
+	newBlocks := make(map[string]ContentBlock, len(new.Blocks))
+	for _, block := range new.Blocks {
+		newBlocks[block.ID] = block
+	}
+	for _, block := range old.Blocks {
+		if _, ok := newBlocks[block.ID]; !ok {
+			diff.Blocks = append(diff.Blocks, BlockChange{Kind: BlockRemoved, ID: block.ID, Type: block.Type, Summary: block.summary()})
+		}
+	}
+	for _, block := range new.Blocks {
+		oldBlock, ok := oldBlocks[block.ID]
+		if !ok {
+			diff.Blocks = append(diff.Blocks, BlockChange{Kind: BlockAdded, ID: block.ID, Type: block.Type, Summary: block.summary()})
+			continue
+		}
+		if !reflect.DeepEqual(jsonValue(oldBlock.written()), jsonValue(block.written())) {
+			diff.Blocks = append(diff.Blocks, BlockChange{Kind: BlockModified, ID: block.ID, Type: block.Type, Summary: block.summary()})
+		}
+		if block.Type.IsMedia() && oldBlock.Type.IsMedia() {
+			change := MediaChange{Block: block.ID, Source: block.RelativeSource}
+			if oldBlock.Hash != block.Hash {
+				change.OldHash, change.NewHash = oldBlock.Hash, block.Hash
+			}
+			change.ThumbnailsRegenerated = !oldBlock.ThumbnailsBuiltAt.Equal(block.ThumbnailsBuiltAt) || !reflect.DeepEqual(oldBlock.Thumbnails, block.Thumbnails)
+			if change.OldHash != change.NewHash || change.ThumbnailsRegenerated {
+				diff.Media = append(diff.Media, change)
+			}
+		}
+	}
+
+	// Compare layouts by block position, so that blocks that got a new ID don't count as a layout change
+	diff.LayoutChanged = !reflect.DeepEqual(layoutByIndex(old.Layout, old.Blocks), layoutByIndex(new.Layout, new.Blocks))
+	for _, breakpoint := range append(sortedKeys(old.Grids), sortedKeys(new.Grids)...) {
+		if !reflect.DeepEqual(layoutByIndex(old.Grids[breakpoint].Layout(), old.Blocks), layoutByIndex(new.Grids[breakpoint].Layout(), new.Blocks)) {
+			diff.LayoutChanged = true
+		}
+	}
+	return diff
+}
+
+// diffFields compares two values decoded from JSON, going into objects to report the fields that changed. Lists are compared as a whole.
+func diffFields(field string, old any, new any) []FieldChange {
+	oldObject, oldIsObject := old.(map[string]any)
+	newObject, newIsObject := new.(map[string]any)
+	if !oldIsObject || !newIsObject {
+		if reflect.DeepEqual(old, new) {
+			return []FieldChange{}
+		}
+		return []FieldChange{{Field: field, Old: old, New: new}}
+	}
+
+	changes := make([]FieldChange, 0)
+	keys := sortedKeys(oldObject)
+	for _, key := range sortedKeys(newObject) {
+		if _, ok := oldObject[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		subfield := key
+		if field != "" {
+			subfield = field + "." + key
+		}
+		changes = append(changes, diffFields(subfield, oldObject[key], newObject[key])...)
+	}
+	return changes
+}
+
+// jsonValue returns value as it would be decoded from the database JSON file, so that values can be compared regardless of nil and empty slices or maps.
+func jsonValue(value any) any {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	json.Unmarshal(encoded, &decoded)
+	return normalizeEmptyJSONValue(decoded)
+}
+
+func normalizeEmptyJSONValue(value any) any {
+	switch value := value.(type) {
+	case []any:
+		if len(value) == 0 {
+			return nil
+		}
+		for i, item := range value {
+			value[i] = normalizeEmptyJSONValue(item)
+		}
+	case map[string]any:
+		if len(value) == 0 {
+			return nil
+		}
+		for key, item := range value {
+			value[key] = normalizeEmptyJSONValue(item)
+		}
+	}
+	return value
+}
+
+// written returns the block without what is computed when building, such as its ID, index and media analysis. Links to other works are kept resolved, since changing where they point to changes the block.
+func (b ContentBlock) written() ContentBlock {
+	return ContentBlock{
+		Type:      b.Type,
+		Anchor:    b.Anchor,
+		Name:      b.Name,
+		Paragraph: b.Paragraph,
+		Link:      b.Link,
+		Media: Media{
+			Alt:            b.Alt,
+			Caption:        b.Caption,
+			RelativeSource: b.RelativeSource,
+			Attributes:     b.Attributes,
+		},
+	}
+}
+
+// summary returns a short plain-text description of the block.
+func (b ContentBlock) summary() string {
+	var text string
+	switch {
+	case b.Type.IsMedia():
+		text = string(b.RelativeSource)
+	case b.Type.IsLink():
+		text = b.Text.plainText() + " (" + b.URL + ")"
+	default:
+		text = b.Content.plainText()
+	}
+	if runes := []rune(text); len(runes) > 60 {
+		text = string(runes[:59]) + "…"
+	}
+	return text
+}
+
+// String returns the diff in a human-readable form, one change per line.
+func (diff DatabaseDiff) String() string {
+	var lines []string
+	for _, id := range diff.Added {
+		lines = append(lines, "+ "+id)
+	}
+	for _, id := range diff.Removed {
+		lines = append(lines, "- "+id)
+	}
+	for _, work := range diff.Changed {
+		lines = append(lines, "~ "+work.ID)
+		for _, change := range work.Metadata {
+			lines = append(lines, fmt.Sprintf("    %s: %s → %s", change.Field, compactJSON(change.Old), compactJSON(change.New)))
+		}
+		for _, language := range work.AddedLanguages {
+			lines = append(lines, fmt.Sprintf("    + %s content", language))
+		}
+		for _, language := range work.RemovedLanguages {
+			lines = append(lines, fmt.Sprintf("    - %s content", language))
+		}
+		for _, content := range work.Content {
+			for _, change := range content.Fields {
+				lines = append(lines, fmt.Sprintf("    %s: %s: %s → %s", content.Language, change.Field, compactJSON(change.Old), compactJSON(change.New)))
+			}
+			for _, block := range content.Blocks {
+				sign := map[BlockChangeKind]string{BlockAdded: "+", BlockRemoved: "-", BlockModified: "~"}[block.Kind]
+				lines = append(lines, fmt.Sprintf("    %s: %s %s block %s %s: %s", content.Language, sign, block.Type, block.ID, block.Kind, block.Summary))
+			}
+			if content.LayoutChanged {
+				lines = append(lines, fmt.Sprintf("    %s: layout changed", content.Language))
+			}
+			for _, media := range content.Media {
+				if media.OldHash != media.NewHash {
+					lines = append(lines, fmt.Sprintf("    %s: media %s changed", content.Language, media.Source))
+				}
+				if media.ThumbnailsRegenerated {
+					lines = append(lines, fmt.Sprintf("    %s: thumbnails of %s regenerated", content.Language, media.Source))
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON returns the diff as indented JSON.
+func (diff DatabaseDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(diff, "", "  ")
+}
+
+func compactJSON(value any) string {
+	if value == nil {
+		return "(none)"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
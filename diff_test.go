@@ -0,0 +1,118 @@
+package ortfodb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffDatabases(t *testing.T) {
+	paragraph := func(id string, content string) ContentBlock {
+		return ContentBlock{ID: id, Type: "paragraph", Paragraph: Paragraph{Content: HTMLString(content)}}
+	}
+	image := func(id string, hash string) ContentBlock {
+		return ContentBlock{ID: id, Type: "media", Media: Media{RelativeSource: "pic.png", Hash: hash}}
+	}
+	work := func(id string, metadata WorkMetadata, content LocalizableContent) Work {
+		return Work{ID: id, Metadata: metadata, Content: content}
+	}
+	single := func(layout Layout, blocks ...ContentBlock) LocalizableContent {
+		return LocalizableContent{"default": {Layout: layout, Blocks: blocks}}
+	}
+
+	tests := []struct {
+		name string
+		old  Database
+		new  Database
+		want DatabaseDiff
+	}{
+		{
+			name: "nothing changed",
+			old:  Database{"a": work("a", WorkMetadata{}, single(nil, paragraph("p1", "<p>Hi</p>")))},
+			new:  Database{"a": work("a", WorkMetadata{}, single(nil, paragraph("p1", "<p>Hi</p>")))},
+			want: DatabaseDiff{},
+		},
+		{
+			name: "added and removed works",
+			old:  Database{"a": work("a", WorkMetadata{}, nil), "b": work("b", WorkMetadata{}, nil)},
+			new:  Database{"b": work("b", WorkMetadata{}, nil), "c": work("c", WorkMetadata{}, nil)},
+			want: DatabaseDiff{Added: []string{"c"}, Removed: []string{"a"}},
+		},
+		{
+			name: "build times and database metadata are ignored",
+			old:  Database{"a": {ID: "a", BuiltAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}},
+			new: Database{"a": {ID: "a", BuiltAt: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), Metadata: WorkMetadata{
+				DatabaseMetadata: DatabaseMeta{Partial: true, DatabaseRepositories: DatabaseRepositories{Tags: []TagUsage{{Tag: Tag{Singular: "web"}}}}},
+			}}},
+			want: DatabaseDiff{},
+		},
+		{
+			name: "metadata",
+			old:  Database{"a": work("a", WorkMetadata{Tags: []string{"web"}}, nil)},
+			new:  Database{"a": work("a", WorkMetadata{Tags: []string{"web", "app"}, WIP: true, Colors: ColorPalette{Primary: "#fff"}}, nil)},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", Metadata: []FieldChange{
+				{Field: "colors.primary", Old: "", New: "#fff"},
+				{Field: "tags", Old: []any{"web"}, New: []any{"web", "app"}},
+				{Field: "wip", Old: false, New: true},
+			}}}},
+		},
+		{
+			name: "languages",
+			old:  Database{"a": work("a", WorkMetadata{}, LocalizableContent{"en": {}, "fr": {}})},
+			new:  Database{"a": work("a", WorkMetadata{}, LocalizableContent{"en": {}, "de": {}})},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", AddedLanguages: []string{"de"}, RemovedLanguages: []string{"fr"}}}},
+		},
+		{
+			name: "blocks",
+			old:  Database{"a": work("a", WorkMetadata{}, single(nil, paragraph("p1", "<p>Hi</p>"), paragraph("p2", "<p>Old</p>"), paragraph("p3", "<p>Same</p>")))},
+			new:  Database{"a": work("a", WorkMetadata{}, single(nil, paragraph("p1", "<p>Hello</p>"), paragraph("p3", "<p>Same</p>"), paragraph("p4", "<p>New</p>")))},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", Content: []ContentDiff{{
+				Language: "default",
+				Blocks: []BlockChange{
+					{Kind: BlockRemoved, ID: "p2", Type: "paragraph", Summary: "Old"},
+					{Kind: BlockModified, ID: "p1", Type: "paragraph", Summary: "Hello"},
+					{Kind: BlockAdded, ID: "p4", Type: "paragraph", Summary: "New"},
+				},
+			}}}}},
+		},
+		{
+			name: "block IDs changing is not a layout change",
+			old:  Database{"a": work("a", WorkMetadata{}, single(Layout{{"p1", "p2"}}, paragraph("p1", "<p>A</p>"), paragraph("p2", "<p>B</p>")))},
+			new:  Database{"a": work("a", WorkMetadata{}, single(Layout{{"intro", "p2"}}, paragraph("intro", "<p>A</p>"), paragraph("p2", "<p>B</p>")))},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", Content: []ContentDiff{{
+				Language: "default",
+				Blocks: []BlockChange{
+					{Kind: BlockRemoved, ID: "p1", Type: "paragraph", Summary: "A"},
+					{Kind: BlockAdded, ID: "intro", Type: "paragraph", Summary: "A"},
+				},
+			}}}}},
+		},
+		{
+			name: "layout",
+			old:  Database{"a": work("a", WorkMetadata{}, single(Layout{{"p1"}, {"p2"}}, paragraph("p1", "<p>A</p>"), paragraph("p2", "<p>B</p>")))},
+			new:  Database{"a": work("a", WorkMetadata{}, single(Layout{{"p1", "p2"}}, paragraph("p1", "<p>A</p>"), paragraph("p2", "<p>B</p>")))},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", Content: []ContentDiff{{Language: "default", LayoutChanged: true}}}}},
+		},
+		{
+			name: "media file",
+			old:  Database{"a": work("a", WorkMetadata{}, single(nil, image("m1", "old")))},
+			new:  Database{"a": work("a", WorkMetadata{}, single(nil, image("m1", "new")))},
+			want: DatabaseDiff{Changed: []WorkDiff{{ID: "a", Content: []ContentDiff{{
+				Language: "default",
+				Media:    []MediaChange{{Block: "m1", Source: "pic.png", OldHash: "old", NewHash: "new"}},
+			}}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffDatabases(tt.old, tt.new)
+			if got.HasChanges() != !reflect.DeepEqual(tt.want, DatabaseDiff{}) {
+				t.Errorf("DiffDatabases().HasChanges() = %v", got.HasChanges())
+			}
+			// Compare as JSON, so that empty and nil slices are the same
+			if !reflect.DeepEqual(jsonValue(got), jsonValue(tt.want)) {
+				t.Errorf("DiffDatabases() = %+v, want %+v", jsonValue(got), jsonValue(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,537 @@
+package ortfodb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// EditOperationKind is what an EditOperation does to the front matter of description files.
+type EditOperationKind string
+
+const (
+	// Set Key to Value, a YAML value.
+	EditSet EditOperationKind = "set"
+	// Remove Key.
+	EditUnset EditOperationKind = "unset"
+	// Add Value to the list at Key, if it is not there already.
+	EditAdd EditOperationKind = "add"
+	// Remove Value from the list at Key.
+	EditRemove EditOperationKind = "remove"
+	// Rename the tag From to To.
+	EditRenameTag EditOperationKind = "rename tag"
+	// Replace the technology From with To.
+	EditReplaceTechnology EditOperationKind = "replace technology"
+)
+
+// EditOperation is a change made to the metadata of works by Edit.
+type EditOperation struct {
+	Kind  EditOperationKind
+	Key   string
+	Value string
+	From  string
+	To    string
+}
+
+func (o EditOperation) String() string {
+	switch o.Kind {
+	case EditSet, EditAdd, EditRemove:
+		return fmt.Sprintf("%s %s=%s", o.Kind, o.Key, o.Value)
+	case EditUnset:
+		return fmt.Sprintf("%s %s", o.Kind, o.Key)
+	}
+	return fmt.Sprintf("%s %s to %s", o.Kind, o.From, o.To)
+}
+
+// ParseEditOperation parses the argument of an edit operation of the given kind: key=value for set, add and remove, key for unset, and old=new for rename tag and replace technology.
+func ParseEditOperation(kind EditOperationKind, argument string) (EditOperation, error) {
+	operation := EditOperation{Kind: kind}
+	if kind == EditUnset {
+		operation.Key = strings.TrimSpace(argument)
+		if operation.Key == "" {
+			return operation, fmt.Errorf("%s needs a key", kind)
+		}
+		return operation, nil
+	}
+
+	left, right, found := strings.Cut(argument, "=")
+	left = strings.TrimSpace(left)
+	switch kind {
+	case EditSet, EditAdd, EditRemove:
+		if !found || left == "" {
+			return operation, fmt.Errorf("%s takes key=value, not %q", kind, argument)
+		}
+		operation.Key, operation.Value = left, right
+	case EditRenameTag, EditReplaceTechnology:
+		right = strings.TrimSpace(right)
+		if !found || left == "" || right == "" {
+			return operation, fmt.Errorf("%s takes old=new, not %q", kind, argument)
+		}
+		operation.From, operation.To = left, right
+	default:
+		return operation, fmt.Errorf("unknown edit operation %q", kind)
+	}
+	return operation, nil
+}
+
+// EditFilter selects works that have a metadata value: Value is compared case-insensitively to the value at Key, or to each of its items if it is a list.
+type EditFilter struct {
+	Key   string
+	Value string
+}
+
+// ParseEditFilter parses a key=value filter.
+func ParseEditFilter(filter string) (EditFilter, error) {
+	key, value, found := strings.Cut(filter, "=")
+	if !found || strings.TrimSpace(key) == "" {
+		return EditFilter{}, fmt.Errorf("filter should be of the form key=value, not %q", filter)
+	}
+	return EditFilter{Key: strings.TrimSpace(key), Value: value}, nil
+}
+
+// Matches returns true if the given front matter values, as decoded from a description file, satisfy the filter.
+func (f EditFilter) Matches(values map[string]interface{}) bool {
+	for key, value := range values {
+		if normalizeFrontMatterKey(key) != normalizeFrontMatterKey(f.Key) {
+			continue
+		}
+		if items, ok := value.([]interface{}); ok {
+			for _, item := range items {
+				if stringsLooselyMatch(fmt.Sprint(item), f.Value) {
+					return true
+				}
+			}
+			return false
+		}
+		return stringsLooselyMatch(fmt.Sprint(value), f.Value)
+	}
+	return false
+}
+
+// EditResult is the outcome of editing the description file of a work.
+type EditResult struct {
+	WorkID string
+	// Path to the description file.
+	File   string
+	Before string
+	After  string
+}
+
+// Changed returns true if the operations changed the description file.
+func (r EditResult) Changed() bool {
+	return r.Before != r.After
+}
+
+// Edit applies operations to the front matter of works matching any of patterns (all works if there are none) and all of filters.
+// Operations are applied in the order they are given. Nothing is written if any of the works cannot be edited, or if dryRun is set.
+// Only YAML front matters can be edited: they are rewritten keeping the order of keys and comments, and the rest of description files is left untouched.
+func (ctx *RunContext) Edit(patterns []string, filters []EditFilter, operations []EditOperation, dryRun bool) ([]EditResult, error) {
+	workDirectories, err := ctx.ComputeProgressTotal()
+	if err != nil {
+		return nil, fmt.Errorf("while listing works: %w", err)
+	}
+
+	// Tags and technologies are renamed along with their aliases
+	if _, err := ctx.LoadTagsRepository(); err != nil {
+		return nil, fmt.Errorf("while loading tags repository: %w", err)
+	}
+	if _, err := ctx.LoadTechnologiesRepository(); err != nil {
+		return nil, fmt.Errorf("while loading technologies repository: %w", err)
+	}
+
+	results := make([]EditResult, 0)
+	for _, dirEntry := range workDirectories {
+		workID := dirEntry.Name()
+		included := len(patterns) == 0
+		for _, pattern := range patterns {
+			matched, err := filepath.Match(pattern, workID)
+			if err != nil {
+				return nil, fmt.Errorf("while testing pattern %q: %w", pattern, err)
+			}
+			included = included || matched
+		}
+		if !included {
+			continue
+		}
+
+		filename := ctx.DescriptionFilename(ctx.DatabaseDirectory, workID)
+		raw, err := readFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("while reading description file %s: %w", filename, err)
+		}
+
+		if len(filters) > 0 {
+			frontMatter, _, err := splitFrontMatter(raw)
+			if err != nil {
+				return nil, fmt.Errorf("while reading front matter of %s: %w", workID, err)
+			}
+			values, err := frontMatter.decodeRaw()
+			if err != nil {
+				return nil, fmt.Errorf("while reading front matter of %s: %w", workID, err)
+			}
+			values = normalizeFrontMatterValue(values).(map[string]interface{})
+			matchesAll := true
+			for _, filter := range filters {
+				matchesAll = matchesAll && filter.Matches(values)
+			}
+			if !matchesAll {
+				continue
+			}
+		}
+
+		edited, err := ctx.EditDescription(raw, operations)
+		if err != nil {
+			return nil, fmt.Errorf("while editing %s: %w", workID, err)
+		}
+		results = append(results, EditResult{WorkID: workID, File: filename, Before: raw, After: edited})
+	}
+
+	if dryRun {
+		return results, nil
+	}
+
+	for _, result := range results {
+		if !result.Changed() {
+			continue
+		}
+		info, err := os.Stat(result.File)
+		if err != nil {
+			return results, fmt.Errorf("while getting permissions of %s: %w", result.File, err)
+		}
+		if err := os.WriteFile(result.File, []byte(result.After), info.Mode().Perm()); err != nil {
+			return results, fmt.Errorf("while writing description file %s: %w", result.File, err)
+		}
+	}
+	return results, nil
+}
+
+// EditDescription applies operations to the front matter of a description file and returns the new contents of the file.
+// A YAML front matter is added to files that have none.
+func (ctx *RunContext) EditDescription(descriptionRaw string, operations []EditOperation) (string, error) {
+	frontMatter, _, err := splitFrontMatter(descriptionRaw)
+	if err != nil {
+		return descriptionRaw, err
+	}
+	if frontMatter.Format != FrontMatterYAML && frontMatter.Format != FrontMatterNone {
+		return descriptionRaw, fmt.Errorf("only YAML front matters can be edited, this one is written in %s", frontMatter.Format)
+	}
+
+	// Locate the front matter ourselves, splitFrontMatter changes indentation of the markdown content
+	lines := strings.Split(descriptionRaw, "\n")
+	first, end := 0, 0
+	for first < len(lines) && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if frontMatter.Format == FrontMatterYAML {
+		for end = first + 1; end < len(lines) && !regexp.MustCompile(PatternYAMLSeparator).MatchString(lines[end]); end++ {
+		}
+	}
+
+	var document yamlv3.Node
+	if frontMatter.Format == FrontMatterYAML {
+		if err := yamlv3.Unmarshal([]byte(frontMatter.Raw), &document); err != nil {
+			return descriptionRaw, fmt.Errorf("while parsing front matter: %w", err)
+		}
+	}
+	if document.Kind == 0 {
+		document = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode}}}
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yamlv3.MappingNode {
+		return descriptionRaw, fmt.Errorf("front matter is not a mapping of keys to values")
+	}
+
+	changed := false
+	for _, operation := range operations {
+		operationChanged, err := ctx.applyEditOperation(mapping, operation)
+		if err != nil {
+			return descriptionRaw, fmt.Errorf("could not %s: %w", operation, err)
+		}
+		changed = changed || operationChanged
+	}
+	if !changed {
+		return descriptionRaw, nil
+	}
+
+	var header bytes.Buffer
+	encoder := yamlv3.NewEncoder(&header)
+	encoder.SetIndent(2)
+	if len(mapping.Content) > 0 {
+		if err := encoder.Encode(&document); err != nil {
+			return descriptionRaw, fmt.Errorf("while writing front matter: %w", err)
+		}
+	}
+
+	var edited string
+	if frontMatter.Format == FrontMatterNone {
+		edited = "---\n" + header.String() + "---\n\n" + descriptionRaw
+	} else {
+		edited = strings.Join(lines[:first+1], "\n") + "\n" + header.String() + strings.Join(lines[end:], "\n")
+	}
+
+	// Don't write metadata that the build would refuse, unless it already did
+	_, _, _, errBefore := ParseFrontMatter[WorkMetadata](descriptionRaw)
+	if _, _, _, err := ParseFrontMatter[WorkMetadata](edited); err != nil && errBefore == nil {
+		return descriptionRaw, fmt.Errorf("the edited front matter would be invalid: %w", err)
+	}
+	return edited, nil
+}
+
+// applyEditOperation applies operation to the front matter's mapping, and returns true if it changed anything.
+func (ctx *RunContext) applyEditOperation(mapping *yamlv3.Node, operation EditOperation) (bool, error) {
+	switch operation.Kind {
+	case EditSet:
+		value, err := yamlValueNode(operation.Value)
+		if err != nil {
+			return false, err
+		}
+		index := yamlMappingIndex(mapping, operation.Key)
+		if index == -1 {
+			mapping.Content = append(mapping.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: frontMatterKeyName(operation.Key)}, value)
+			return true, nil
+		}
+		current := mapping.Content[index+1]
+		if yamlNodesEqual(current, value) {
+			return false, nil
+		}
+		value.HeadComment, value.LineComment, value.FootComment = current.HeadComment, current.LineComment, current.FootComment
+		mapping.Content[index+1] = value
+		return true, nil
+
+	case EditUnset:
+		index := yamlMappingIndex(mapping, operation.Key)
+		if index == -1 {
+			return false, nil
+		}
+		mapping.Content = append(mapping.Content[:index], mapping.Content[index+2:]...)
+		return true, nil
+
+	case EditAdd:
+		list := yamlMappingList(mapping, operation.Key)
+		for _, item := range list.Content {
+			if stringsLooselyMatch(item.Value, operation.Value) {
+				return false, nil
+			}
+		}
+		list.Content = append(list.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: operation.Value})
+		return true, nil
+
+	case EditRemove:
+		if yamlMappingIndex(mapping, operation.Key) == -1 {
+			return false, nil
+		}
+		return yamlReplaceItems(yamlMappingList(mapping, operation.Key), func(item string) bool {
+			return stringsLooselyMatch(item, operation.Value)
+		}, ""), nil
+
+	case EditRenameTag:
+		refersToTag := func(item string) bool { return stringsLooselyMatch(item, operation.From) }
+		if tag, ok := ctx.FindTag(operation.From); ok {
+			refersToTag = tag.ReferredToBy
+		}
+		changed := false
+		for _, tags := range yamlTagsLists(mapping) {
+			changed = yamlReplaceItems(tags, refersToTag, operation.To) || changed
+		}
+		return changed, nil
+
+	case EditReplaceTechnology:
+		if yamlMappingIndex(mapping, "made with") == -1 {
+			return false, nil
+		}
+		refersToTechnology := func(item string) bool { return stringsLooselyMatch(item, operation.From) }
+		if tech, ok := ctx.FindTechnology(operation.From); ok {
+			refersToTechnology = tech.ReferredToBy
+		}
+		return yamlReplaceItems(yamlMappingList(mapping, "made with"), refersToTechnology, operation.To), nil
+	}
+	return false, fmt.Errorf("unknown edit operation %q", operation.Kind)
+}
+
+// frontMatterKeyName returns how key should be written in a YAML front matter: as the name of the WorkMetadata field it refers to, or as-is for additional metadata.
+func frontMatterKeyName(key string) string {
+	normalized := normalizeFrontMatterKey(key)
+	if name := (FrontMatter{Format: FrontMatterYAML}).fieldDisplayName(reflect.TypeOf(WorkMetadata{}), normalized); name != normalized {
+		return name
+	}
+	return key
+}
+
+// yamlMappingIndex returns the index in mapping's content of the given key, compared with normalizeFrontMatterKey, or -1.
+func yamlMappingIndex(mapping *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if normalizeFrontMatterKey(mapping.Content[i].Value) == normalizeFrontMatterKey(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlMappingList returns the list at key, creating it if needed. Single values are turned into lists of one item.
+func yamlMappingList(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	list := &yamlv3.Node{Kind: yamlv3.SequenceNode, Style: yamlv3.FlowStyle}
+	index := yamlMappingIndex(mapping, key)
+	if index == -1 {
+		mapping.Content = append(mapping.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Value: frontMatterKeyName(key)}, list)
+		return list
+	}
+
+	current := mapping.Content[index+1]
+	if current.Kind == yamlv3.SequenceNode {
+		return current
+	}
+	if current.Kind == yamlv3.ScalarNode && current.Tag != "!!null" {
+		list.Content = append(list.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: current.Value})
+	}
+	list.HeadComment, list.LineComment, list.FootComment = current.HeadComment, current.LineComment, current.FootComment
+	mapping.Content[index+1] = list
+	return list
+}
+
+// yamlTagsLists returns the lists of tags of the front matter: the work's, and the ones it has in each language, under localized.<language>.tags.
+func yamlTagsLists(mapping *yamlv3.Node) []*yamlv3.Node {
+	lists := make([]*yamlv3.Node, 0)
+	if yamlMappingIndex(mapping, "tags") != -1 {
+		lists = append(lists, yamlMappingList(mapping, "tags"))
+	}
+	index := yamlMappingIndex(mapping, "localized")
+	if index == -1 || mapping.Content[index+1].Kind != yamlv3.MappingNode {
+		return lists
+	}
+	languages := mapping.Content[index+1].Content
+	for i := 1; i < len(languages); i += 2 {
+		if languages[i].Kind == yamlv3.MappingNode && yamlMappingIndex(languages[i], "tags") != -1 {
+			lists = append(lists, yamlMappingList(languages[i], "tags"))
+		}
+	}
+	return lists
+}
+
+// yamlReplaceItems replaces items of list that match with replacement, or removes them if replacement is empty or already in the list. Returns true if list changed.
+func yamlReplaceItems(list *yamlv3.Node, matches func(item string) bool, replacement string) bool {
+	hasReplacement := false
+	for _, item := range list.Content {
+		hasReplacement = hasReplacement || (replacement != "" && item.Value == replacement)
+	}
+
+	changed := false
+	items := make([]*yamlv3.Node, 0, len(list.Content))
+	for _, item := range list.Content {
+		if item.Kind != yamlv3.ScalarNode || item.Value == replacement || !matches(item.Value) {
+			items = append(items, item)
+			continue
+		}
+		changed = true
+		if replacement != "" && !hasReplacement {
+			item.Value, item.Tag, item.Style = replacement, "!!str", 0
+			items = append(items, item)
+			hasReplacement = true
+		}
+	}
+	list.Content = items
+	return changed
+}
+
+// yamlValueNode parses a YAML value given on the command line.
+func yamlValueNode(value string) (*yamlv3.Node, error) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(value), &document); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+	return document.Content[0], nil
+}
+
+func yamlNodesEqual(a, b *yamlv3.Node) bool {
+	var encodedA, encodedB bytes.Buffer
+	if yamlv3.NewEncoder(&encodedA).Encode(a) != nil || yamlv3.NewEncoder(&encodedB).Encode(b) != nil {
+		return false
+	}
+	return encodedA.String() == encodedB.String()
+}
+
+// Diff returns the changes made to the description file, in the unified diff format.
+func (r EditResult) Diff() string {
+	if !r.Changed() {
+		return ""
+	}
+	before, after := strings.Split(strings.TrimSuffix(r.Before, "\n"), "\n"), strings.Split(strings.TrimSuffix(r.After, "\n"), "\n")
+
+	// Longest common subsequence of lines. Description files are small enough.
+	common := make([][]int, len(before)+1)
+	for i := range common {
+		common[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	type diffLine struct {
+		prefix        byte
+		text          string
+		line, newLine int
+	}
+	diffLines := make([]diffLine, 0)
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			diffLines = append(diffLines, diffLine{' ', before[i], i, j})
+			i, j = i+1, j+1
+		case i < len(before) && (j == len(after) || common[i+1][j] >= common[i][j+1]):
+			diffLines = append(diffLines, diffLine{'-', before[i], i, j})
+			i++
+		default:
+			diffLines = append(diffLines, diffLine{'+', after[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", r.File, r.File)
+	for start := 0; start < len(diffLines); {
+		if diffLines[start].prefix == ' ' {
+			start++
+			continue
+		}
+		// Extend the hunk while changes are close enough to each other
+		hunkStart, hunkEnd := max(start-context, 0), start
+		for k := start; k < len(diffLines) && k <= hunkEnd+2*context; k++ {
+			if diffLines[k].prefix != ' ' {
+				hunkEnd = k
+			}
+		}
+		hunkEnd = min(hunkEnd+context, len(diffLines)-1)
+
+		removed, added := 0, 0
+		for _, line := range diffLines[hunkStart : hunkEnd+1] {
+			if line.prefix != '+' {
+				removed++
+			}
+			if line.prefix != '-' {
+				added++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", diffLines[hunkStart].line+1, removed, diffLines[hunkStart].newLine+1, added)
+		for _, line := range diffLines[hunkStart : hunkEnd+1] {
+			fmt.Fprintf(&diff, "%c%s\n", line.prefix, line.text)
+		}
+		start = hunkEnd + 1
+	}
+	return diff.String()
+}
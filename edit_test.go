@@ -0,0 +1,68 @@
+package ortfodb
+
+import "testing"
+
+func TestEditDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		operations  []EditOperation
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "operations are applied in order",
+			description: "---\ntags: [design]\n---\n# Hello\n",
+			operations: []EditOperation{
+				{Kind: EditRemove, Key: "tags", Value: "design"},
+				{Kind: EditAdd, Key: "tags", Value: "design"},
+			},
+			want: "---\ntags: [design]\n---\n# Hello\n",
+		},
+		{
+			name:        "set and unset keep comments and other keys",
+			description: "---\n# Dates\nstarted: 2021-05 # roughly\nwip: true\n---\n# Hello\n",
+			operations: []EditOperation{
+				{Kind: EditSet, Key: "started", Value: "2021-06"},
+				{Kind: EditUnset, Key: "wip"},
+			},
+			want: "---\n# Dates\nstarted: 2021-06 # roughly\n---\n# Hello\n",
+		},
+		{
+			name:        "rename tag in every language",
+			description: "---\ntags: [design, web]\nlocalized:\n  fr:\n    tags: [design]\n---\n# Hello\n",
+			operations:  []EditOperation{{Kind: EditRenameTag, From: "design", To: "graphics"}},
+			want:        "---\ntags: [graphics, web]\nlocalized:\n  fr:\n    tags: [graphics]\n---\n# Hello\n",
+		},
+		{
+			name:        "no front matter",
+			description: "# Hello\n",
+			operations:  []EditOperation{{Kind: EditAdd, Key: "tags", Value: "web"}},
+			want:        "---\ntags: [web]\n---\n\n# Hello\n",
+		},
+		{
+			name:        "nothing to change",
+			description: "---\ntags: [web]\n---\n# Hello\n",
+			operations:  []EditOperation{{Kind: EditRemove, Key: "made with", Value: "go"}},
+			want:        "---\ntags: [web]\n---\n# Hello\n",
+		},
+		{
+			name:        "invalid value",
+			description: "---\nwip: true\n---\n# Hello\n",
+			operations:  []EditOperation{{Kind: EditSet, Key: "wip", Value: "maybe"}},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &RunContext{}
+			got, err := ctx.EditDescription(tt.description, tt.operations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EditDescription() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("EditDescription() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package ortfodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	FeedRSS  = "rss"
+	FeedAtom = "atom"
+	FeedJSON = "json"
+)
+
+type FeedsExporterOptions struct {
+	// Title and description of the feeds.
+	Title       string `yaml:"title"`
+	Description string `yaml:"description,omitempty"`
+	// Name of the author of the works.
+	Author string `yaml:"author,omitempty"`
+	// Formats to generate, among rss, atom and json (for JSON Feed). Defaults to all of them.
+	Formats []string `yaml:"formats,omitempty"`
+	// Go template for the path of each feed file. Receives .Lang and .Format. Defaults to "feeds/{{ .Lang }}.{{ .Format }}".
+	Output string `yaml:"output,omitempty"`
+	// URL of the site, used to turn relative URLs into absolute ones, as feeds require.
+	SiteURL string `yaml:"site_url"`
+	// Go template for the URL each feed will be available at, so that feed readers can find it again. Receives .Lang and .Format.
+	FeedURL string `yaml:"feed_url,omitempty"`
+	// Template for the URL of works, with <work id> and <language> placeholders. Defaults to links.work url from the configuration.
+	WorkURL string `yaml:"work_url,omitempty"`
+	// Template for the URL of media files, with a <path> placeholder for the file's path inside the media directory. Defaults to "<path>".
+	MediaURL string `yaml:"media_url,omitempty"`
+	// Size of the thumbnail to use as the image of entries. Defaults to 600.
+	ThumbnailSize int `yaml:"thumbnail_size,omitempty"`
+	// Maximum number of entries in each feed. 0 means no limit.
+	Limit int `yaml:"limit,omitempty"`
+}
+
+type FeedsExporter struct {
+}
+
+func (e *FeedsExporter) OptionsType() any {
+	return FeedsExporterOptions{}
+}
+
+func (e *FeedsExporter) Name() string {
+	return "feeds"
+}
+
+func (e *FeedsExporter) Description() string {
+	return "Generate RSS, Atom and JSON Feed files for each language, so that people can follow new works. Private works and works in progress are left out."
+}
+
+func (e *FeedsExporter) Before(ctx *RunContext, opts PluginOptions) error {
+	return nil
+}
+
+func (e *FeedsExporter) Export(ctx *RunContext, opts PluginOptions, work *Work) error {
+	return nil
+}
+
+// feedEntry is a work as it appears in feeds, in a given language.
+type feedEntry struct {
+	ID          string
+	URL         string
+	Title       string
+	Summary     HTMLString
+	Image       string
+	Categories  []string
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+}
+
+func (e *FeedsExporter) After(ctx *RunContext, opts PluginOptions, db *Database) error {
+	options := GetPluginOptions[FeedsExporterOptions](e, opts)
+	if options.SiteURL == "" {
+		return fmt.Errorf("site_url is not set, but feeds need it to use absolute URLs")
+	}
+	if len(options.Formats) == 0 {
+		options.Formats = []string{FeedRSS, FeedAtom, FeedJSON}
+	}
+	if options.Output == "" {
+		options.Output = "feeds/{{ .Lang }}.{{ .Format }}"
+	}
+	if options.ThumbnailSize == 0 {
+		options.ThumbnailSize = 600
+	}
+	for _, format := range options.Formats {
+		if !stringInSlice([]string{FeedRSS, FeedAtom, FeedJSON}, format) {
+			return fmt.Errorf("unknown feed format %q, use rss, atom or json", format)
+		}
+	}
+	outputTemplate, err := template.New("output").Parse(options.Output)
+	if err != nil {
+		return fmt.Errorf("while parsing output filename template %q: %w", options.Output, err)
+	}
+	feedURLTemplate, err := template.New("feed url").Parse(options.FeedURL)
+	if err != nil {
+		return fmt.Errorf("while parsing feed URL template %q: %w", options.FeedURL, err)
+	}
+
+	languages := db.Languages()
+	if len(languages) == 0 {
+		languages = []string{"default"}
+	}
+	sort.Strings(languages)
+
+	for _, language := range languages {
+		entries := e.entries(ctx, options, *db, language)
+		for _, format := range options.Formats {
+			var outputFilename, feedURL strings.Builder
+			err := outputTemplate.Execute(&outputFilename, map[string]any{"Lang": language, "Format": format})
+			if err != nil {
+				return fmt.Errorf("while computing output filename for the %s feed in %s: %w", format, language, err)
+			}
+			err = feedURLTemplate.Execute(&feedURL, map[string]any{"Lang": language, "Format": format})
+			if err != nil {
+				return fmt.Errorf("while computing the URL of the %s feed in %s: %w", format, language, err)
+			}
+			self := ""
+			if feedURL.Len() > 0 {
+				self = absoluteURL(options.SiteURL, feedURL.String())
+			}
+
+			var feed []byte
+			switch format {
+			case FeedRSS:
+				feed, err = rssFeed(options, language, entries)
+			case FeedAtom:
+				feed, err = atomFeed(options, language, entries, self)
+			case FeedJSON:
+				feed, err = jsonFeed(options, language, entries, self)
+			}
+			if err != nil {
+				return fmt.Errorf("while generating the %s feed in %s: %w", format, language, err)
+			}
+
+			if err := writeExportedFile(outputFilename.String(), feed); err != nil {
+				return err
+			}
+			PluginLogCustom(e, "Exported", "green", "%s feed in %s to %s", format, language, outputFilename.String())
+		}
+	}
+	return nil
+}
+
+// entries returns the public, finished works of the database as feed entries in the given language, most recent first.
+func (e *FeedsExporter) entries(ctx *RunContext, options FeedsExporterOptions, db Database, language string) []feedEntry {
+	entries := make([]feedEntry, 0, len(db))
+	for _, work := range db.Works() {
+		if work.Metadata.Private || work.Metadata.WIP {
+			continue
+		}
+
+		// Works that are not translated are in the default language
+		contentLanguage := language
+		if _, ok := work.Content[language]; !ok {
+			contentLanguage = "default"
+		}
+
+		entry := feedEntry{
+			ID:          work.ID,
+			URL:         absoluteURL(options.SiteURL, workURL(ctx, options.WorkURL, language, work.ID)),
+			Title:       work.Content.Localize(language).Title.plainText(),
+			PublishedAt: work.FirstBuiltAt,
+			UpdatedAt:   work.DescriptionChangedAt,
+		}
+		// Databases built before these dates were tracked
+		if entry.PublishedAt.IsZero() {
+			entry.PublishedAt = work.BuiltAt
+		}
+		if entry.UpdatedAt.IsZero() {
+			entry.UpdatedAt = work.BuiltAt
+		}
+		if entry.Title == "" {
+			entry.Title = work.ID
+		}
+		if found, paragraph := work.FirstParagraph(contentLanguage); found {
+			entry.Summary = paragraph.Content
+		}
+		if created, err := work.Metadata.CreationDate(); err == nil && created.Known() && created.Time().Before(entry.UpdatedAt) {
+			entry.PublishedAt = created.Time()
+		}
+
+		thumbnail := work.ThumbnailPath(language, options.ThumbnailSize)
+		if thumbnailBlock := work.ThumbnailBlock(language); thumbnail == "" && strings.HasPrefix(thumbnailBlock.ContentType, "image/") {
+			thumbnail = thumbnailBlock.DistSource
+		}
+		if thumbnail != "" {
+			entry.Image = absoluteURL(options.SiteURL, mediaURL(options.MediaURL, thumbnail))
+		}
+
+		for _, name := range work.Metadata.Localize(language).Tags {
+			if tag, ok := db.Tag(name); ok {
+				name = tag.Localize(language).Singular
+			}
+			entry.Categories = append(entry.Categories, name)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].PublishedAt.Equal(entries[j].PublishedAt) {
+			return entries[i].ID < entries[j].ID
+		}
+		return entries[i].PublishedAt.After(entries[j].PublishedAt)
+	})
+	if options.Limit > 0 && len(entries) > options.Limit {
+		entries = entries[:options.Limit]
+	}
+	return entries
+}
+
+// lastUpdate returns the most recent update time of the given entries.
+func lastUpdate(entries []feedEntry) time.Time {
+	var last time.Time
+	for _, entry := range entries {
+		if entry.UpdatedAt.After(last) {
+			last = entry.UpdatedAt
+		}
+	}
+	return last
+}
+
+func rssFeed(options FeedsExporterOptions, language string, entries []feedEntry) ([]byte, error) {
+	type guid struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	}
+	type enclosure struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length int    `xml:"length,attr"`
+	}
+	type item struct {
+		Title       string     `xml:"title"`
+		Link        string     `xml:"link"`
+		GUID        guid       `xml:"guid"`
+		Description string     `xml:"description,omitempty"`
+		PubDate     string     `xml:"pubDate"`
+		Categories  []string   `xml:"category"`
+		Enclosure   *enclosure `xml:"enclosure"`
+	}
+	type channel struct {
+		Title         string `xml:"title"`
+		Link          string `xml:"link"`
+		Description   string `xml:"description"`
+		Language      string `xml:"language,omitempty"`
+		LastBuildDate string `xml:"lastBuildDate,omitempty"`
+		Items         []item `xml:"item"`
+	}
+	type rss struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel channel  `xml:"channel"`
+	}
+
+	feed := rss{Version: "2.0", Channel: channel{
+		Title:       options.Title,
+		Link:        options.SiteURL,
+		Description: options.Description,
+		Language:    feedLanguage(language),
+	}}
+	if updated := lastUpdate(entries); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+	for _, entry := range entries {
+		rssItem := item{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			GUID:        guid{IsPermaLink: true, Value: entry.URL},
+			Description: string(entry.Summary),
+			PubDate:     entry.PublishedAt.Format(time.RFC1123Z),
+			Categories:  entry.Categories,
+		}
+		if entry.Image != "" {
+			rssItem.Enclosure = &enclosure{URL: entry.Image, Type: imageContentType(entry.Image)}
+		}
+		feed.Channel.Items = append(feed.Channel.Items, rssItem)
+	}
+	return marshalXMLFeed(feed)
+}
+
+func atomFeed(options FeedsExporterOptions, language string, entries []feedEntry, feedURL string) ([]byte, error) {
+	type link struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+		Type string `xml:"type,attr,omitempty"`
+	}
+	type text struct {
+		Type  string `xml:"type,attr,omitempty"`
+		Value string `xml:",chardata"`
+	}
+	type category struct {
+		Term string `xml:"term,attr"`
+	}
+	type author struct {
+		Name string `xml:"name"`
+	}
+	type entry struct {
+		ID         string     `xml:"id"`
+		Title      string     `xml:"title"`
+		Links      []link     `xml:"link"`
+		Published  string     `xml:"published"`
+		Updated    string     `xml:"updated"`
+		Summary    *text      `xml:"summary"`
+		Categories []category `xml:"category"`
+	}
+	type feed struct {
+		XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Language string   `xml:"xml:lang,attr,omitempty"`
+		ID       string   `xml:"id"`
+		Title    string   `xml:"title"`
+		Subtitle string   `xml:"subtitle,omitempty"`
+		Links    []link   `xml:"link"`
+		Updated  string   `xml:"updated"`
+		Author   *author  `xml:"author"`
+		Entries  []entry  `xml:"entry"`
+	}
+
+	atom := feed{
+		Language: feedLanguage(language),
+		ID:       options.SiteURL,
+		Title:    options.Title,
+		Subtitle: options.Description,
+		Links:    []link{{Href: options.SiteURL}},
+		Updated:  lastUpdate(entries).Format(time.RFC3339),
+	}
+	if feedURL != "" {
+		atom.ID = feedURL
+		atom.Links = append(atom.Links, link{Href: feedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+	if options.Author != "" {
+		atom.Author = &author{Name: options.Author}
+	}
+	for _, work := range entries {
+		atomEntry := entry{
+			ID:        work.URL,
+			Title:     work.Title,
+			Links:     []link{{Href: work.URL, Rel: "alternate"}},
+			Published: work.PublishedAt.Format(time.RFC3339),
+			Updated:   work.UpdatedAt.Format(time.RFC3339),
+		}
+		if work.Summary != "" {
+			atomEntry.Summary = &text{Type: "html", Value: string(work.Summary)}
+		}
+		if work.Image != "" {
+			atomEntry.Links = append(atomEntry.Links, link{Href: work.Image, Rel: "enclosure", Type: imageContentType(work.Image)})
+		}
+		for _, name := range work.Categories {
+			atomEntry.Categories = append(atomEntry.Categories, category{Term: name})
+		}
+		atom.Entries = append(atom.Entries, atomEntry)
+	}
+	return marshalXMLFeed(atom)
+}
+
+func jsonFeed(options FeedsExporterOptions, language string, entries []feedEntry, feedURL string) ([]byte, error) {
+	type author struct {
+		Name string `json:"name"`
+	}
+	type item struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url"`
+		Title         string   `json:"title"`
+		ContentHTML   string   `json:"content_html"`
+		Image         string   `json:"image,omitempty"`
+		DatePublished string   `json:"date_published"`
+		DateModified  string   `json:"date_modified"`
+		Tags          []string `json:"tags,omitempty"`
+	}
+	type feed struct {
+		Version     string   `json:"version"`
+		Title       string   `json:"title"`
+		HomePageURL string   `json:"home_page_url,omitempty"`
+		FeedURL     string   `json:"feed_url,omitempty"`
+		Description string   `json:"description,omitempty"`
+		Language    string   `json:"language,omitempty"`
+		Authors     []author `json:"authors,omitempty"`
+		Items       []item   `json:"items"`
+	}
+
+	jsonFeed := feed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       options.Title,
+		HomePageURL: options.SiteURL,
+		FeedURL:     feedURL,
+		Description: options.Description,
+		Language:    feedLanguage(language),
+		Items:       make([]item, 0, len(entries)),
+	}
+	if options.Author != "" {
+		jsonFeed.Authors = []author{{Name: options.Author}}
+	}
+	for _, entry := range entries {
+		jsonFeed.Items = append(jsonFeed.Items, item{
+			ID:            entry.URL,
+			URL:           entry.URL,
+			Title:         entry.Title,
+			ContentHTML:   string(entry.Summary),
+			Image:         entry.Image,
+			DatePublished: entry.PublishedAt.Format(time.RFC3339),
+			DateModified:  entry.UpdatedAt.Format(time.RFC3339),
+			Tags:          entry.Categories,
+		})
+	}
+	// Don't escape the HTML of items' content
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(jsonFeed)
+	return out.Bytes(), err
+}
+
+func marshalXMLFeed(feed any) ([]byte, error) {
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// feedLanguage returns the language code to declare in feeds, which is empty for the "default" language.
+func feedLanguage(language string) string {
+	if language == "default" {
+		return ""
+	}
+	return language
+}
+
+// workURL returns the URL of the given work in the given language, from the given template with <work id> and <language> placeholders, or from links.work url if template is empty.
+func workURL(ctx *RunContext, template string, language string, workID string) string {
+	if template == "" {
+		return ctx.WorkURL(language, workID, "")
+	}
+	return strings.NewReplacer("<work id>", workID, "<language>", language).Replace(template)
+}
+
+// mediaURL returns the URL of the given media file, from the given template with a <path> placeholder, or the path itself if template is empty.
+func mediaURL(template string, path FilePathInsideMediaRoot) string {
+	if template == "" {
+		template = "<path>"
+	}
+	return strings.ReplaceAll(template, "<path>", filepath.ToSlash(string(path)))
+}
+
+// absoluteURL prefixes url with siteURL, unless it's already absolute or siteURL is empty.
+func absoluteURL(siteURL string, url string) string {
+	if siteURL == "" || isValidURL(url) {
+		return url
+	}
+	return strings.TrimRight(siteURL, "/") + "/" + strings.TrimLeft(url, "/")
+}
+
+// imageContentType guesses the content type of an image from its URL.
+func imageContentType(url string) string {
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(url), "."))
+	switch extension {
+	case "jpg":
+		return "image/jpeg"
+	case "svg":
+		return "image/svg+xml"
+	case "":
+		return "image/*"
+	}
+	if slices.Contains([]string{"png", "jpeg", "gif", "webp", "avif"}, extension) {
+		return "image/" + extension
+	}
+	return "image/*"
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// plainText returns the text of the HTML, without tags and with whitespace collapsed.
+func (h HTMLString) plainText() string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagPattern.ReplaceAllString(string(h), ""))), " ")
+}
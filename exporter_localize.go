@@ -3,11 +3,11 @@ package ortfodb
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"text/template"
 
 	jsoniter "github.com/json-iterator/go"
-	"github.com/mitchellh/mapstructure"
 )
 
 type LocalizeExporter struct {
@@ -26,7 +26,7 @@ func (e *LocalizeExporter) Name() string {
 }
 
 func (e *LocalizeExporter) Description() string {
-	return "Export separately the database as a single database for each language. The `content` field of each work is localized, meaning it's not an object mapping languages to localized content, but the content directly, in the language."
+	return "Export separately the database as a single database for each language. The `content` field of each work is localized, meaning it's not an object mapping languages to localized content, but the content directly, in the language. Tags, technologies and collections are localized too."
 }
 
 func (e *LocalizeExporter) Before(ctx *RunContext, opts PluginOptions) error {
@@ -45,13 +45,32 @@ func (e *LocalizeExporter) After(ctx *RunContext, opts PluginOptions, db *Databa
 	}
 
 	for _, lang := range db.Languages() {
-		out := make(map[string]map[string]any)
+		out := make(map[string]any)
 		for id, work := range db.Works() {
+			work.Tags = slices.Clone(work.Tags)
+			for i, tag := range work.Tags {
+				work.Tags[i] = tag.Localize(lang)
+			}
+			work.Technologies = slices.Clone(work.Technologies)
+			for i, technology := range work.Technologies {
+				work.Technologies[i] = technology.Localize(lang)
+			}
+			// Go through JSON to get the same keys as in the database
+			workJSON, err := jsoniter.ConfigFastest.Marshal(work)
+			if err != nil {
+				return fmt.Errorf("while marshaling work %s to JSON: %w", id, err)
+			}
 			localizedWork := make(map[string]any)
-			mapstructure.Decode(work, localizedWork)
+			err = jsoniter.ConfigFastest.Unmarshal(workJSON, &localizedWork)
+			if err != nil {
+				return fmt.Errorf("while unmarshaling work %s from JSON: %w", id, err)
+			}
 			localizedWork["content"] = work.Content.Localize(lang)
 			out[id] = localizedWork
 		}
+		if len(*db) > 0 {
+			out[DatabaseMetaKey] = db.Meta().Localize(lang).DatabaseRepositories
+		}
 		var outputFilename strings.Builder
 		err := outputFilenameTemplate.Execute(&outputFilename, map[string]any{"Lang": lang})
 		if err != nil {
@@ -0,0 +1,321 @@
+package ortfodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+type SitemapExporterOptions struct {
+	// URL of the site, used to turn relative URLs into absolute ones, as sitemaps and structured data require.
+	SiteURL string `yaml:"site_url"`
+	// Template for the URL of works, with <work id> and <language> placeholders. Defaults to links.work url from the configuration.
+	WorkURL string `yaml:"work_url,omitempty"`
+	// Template for the URL of media files, with a <path> placeholder for the file's path inside the media directory. Defaults to "<path>".
+	MediaURL string `yaml:"media_url,omitempty"`
+	// Template for the URL of tag pages, with <tag> and <language> placeholders. <tag> is the singular name of the tag in the language. Tag pages are left out of the sitemap if empty.
+	TagURL string `yaml:"tag_url,omitempty"`
+	// Path of the sitemap file. Defaults to "sitemap.xml".
+	Sitemap string `yaml:"sitemap,omitempty"`
+	// Go template for the path of the structured data file of each language. Receives .Lang. Defaults to "structured-data/{{ .Lang }}.json".
+	StructuredData string `yaml:"structured_data,omitempty"`
+	// Name of the author of the works.
+	Author string `yaml:"author,omitempty"`
+}
+
+type SitemapExporter struct {
+}
+
+func (e *SitemapExporter) OptionsType() any {
+	return SitemapExporterOptions{}
+}
+
+func (e *SitemapExporter) Name() string {
+	return "sitemap"
+}
+
+func (e *SitemapExporter) Description() string {
+	return "Generate a sitemap.xml file, with alternate URLs for each language, and schema.org structured data (JSON-LD) for each work, to help search engines. Private works are left out."
+}
+
+func (e *SitemapExporter) Before(ctx *RunContext, opts PluginOptions) error {
+	return nil
+}
+
+func (e *SitemapExporter) Export(ctx *RunContext, opts PluginOptions, work *Work) error {
+	return nil
+}
+
+func (e *SitemapExporter) After(ctx *RunContext, opts PluginOptions, db *Database) error {
+	options := GetPluginOptions[SitemapExporterOptions](e, opts)
+	if options.Sitemap == "" {
+		options.Sitemap = "sitemap.xml"
+	}
+	if options.StructuredData == "" {
+		options.StructuredData = "structured-data/{{ .Lang }}.json"
+	}
+	structuredDataTemplate, err := template.New("structured data").Parse(options.StructuredData)
+	if err != nil {
+		return fmt.Errorf("while parsing structured data filename template %q: %w", options.StructuredData, err)
+	}
+
+	languages := db.Languages()
+	if len(languages) == 0 {
+		languages = []string{"default"}
+	}
+	sort.Strings(languages)
+
+	works := make([]Work, 0, len(*db))
+	for _, id := range sortedKeys(*db) {
+		if !(*db)[id].Metadata.Private {
+			works = append(works, (*db)[id])
+		}
+	}
+
+	sitemap, err := e.sitemap(ctx, options, *db, works, languages)
+	if err != nil {
+		return fmt.Errorf("while generating sitemap: %w", err)
+	}
+	if err := writeExportedFile(options.Sitemap, sitemap); err != nil {
+		return err
+	}
+	PluginLogCustom(e, "Exported", "green", "sitemap to %s", options.Sitemap)
+
+	for _, language := range languages {
+		var outputFilename strings.Builder
+		err := structuredDataTemplate.Execute(&outputFilename, map[string]any{"Lang": language})
+		if err != nil {
+			return fmt.Errorf("while computing output filename for structured data in %s: %w", language, err)
+		}
+
+		structuredData := make(map[string]any, len(works))
+		for _, work := range works {
+			structuredData[work.ID] = e.creativeWork(ctx, options, *db, work, language)
+		}
+
+		var out bytes.Buffer
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(structuredData); err != nil {
+			return fmt.Errorf("while encoding structured data in %s: %w", language, err)
+		}
+		if err := writeExportedFile(outputFilename.String(), out.Bytes()); err != nil {
+			return err
+		}
+		PluginLogCustom(e, "Exported", "green", "structured data in %s to %s", language, outputFilename.String())
+	}
+	return nil
+}
+
+func (e *SitemapExporter) sitemap(ctx *RunContext, options SitemapExporterOptions, db Database, works []Work, languages []string) ([]byte, error) {
+	type alternate struct {
+		Rel      string `xml:"rel,attr"`
+		Hreflang string `xml:"hreflang,attr"`
+		Href     string `xml:"href,attr"`
+	}
+	type sitemapURL struct {
+		Location     string      `xml:"loc"`
+		LastModified string      `xml:"lastmod,omitempty"`
+		Alternates   []alternate `xml:"xhtml:link"`
+	}
+	type urlset struct {
+		XMLName xml.Name     `xml:"urlset"`
+		XMLNS   string       `xml:"xmlns,attr"`
+		XHTML   string       `xml:"xmlns:xhtml,attr"`
+		URLs    []sitemapURL `xml:"url"`
+	}
+
+	sitemap := urlset{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9", XHTML: "http://www.w3.org/1999/xhtml"}
+	// add adds a page, which is available at pageURL(language) in every language
+	add := func(pageURL func(language string) string, lastModified time.Time) {
+		alternates := make([]alternate, 0, len(languages))
+		if len(languages) > 1 {
+			for _, language := range languages {
+				alternates = append(alternates, alternate{Rel: "alternate", Hreflang: language, Href: pageURL(language)})
+			}
+		}
+		for _, language := range languages {
+			page := sitemapURL{Location: pageURL(language), Alternates: alternates}
+			if !lastModified.IsZero() {
+				page.LastModified = lastModified.Format(time.RFC3339)
+			}
+			sitemap.URLs = append(sitemap.URLs, page)
+		}
+	}
+
+	for _, work := range works {
+		add(func(language string) string {
+			return absoluteURL(options.SiteURL, workURL(ctx, options.WorkURL, language, work.ID))
+		}, work.BuiltAt)
+	}
+
+	if options.TagURL != "" {
+		for _, tag := range db.Tags() {
+			var lastModified time.Time
+			tagged := slices.DeleteFunc(db.WorksTagged(tag.Singular), func(work Work) bool { return work.Metadata.Private })
+			if len(tagged) == 0 {
+				continue
+			}
+			for _, work := range tagged {
+				if work.BuiltAt.After(lastModified) {
+					lastModified = work.BuiltAt
+				}
+			}
+			add(func(language string) string {
+				return absoluteURL(options.SiteURL, tagURL(options.TagURL, language, tag.Localize(language).Singular))
+			}, lastModified)
+		}
+	}
+
+	out, err := xml.MarshalIndent(sitemap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// creativeWork returns the schema.org CreativeWork that describes the work in the given language, with its images and videos.
+func (e *SitemapExporter) creativeWork(ctx *RunContext, options SitemapExporterOptions, db Database, work Work, language string) map[string]any {
+	content := work.Content.Localize(language)
+	pageURL := absoluteURL(options.SiteURL, workURL(ctx, options.WorkURL, language, work.ID))
+	creativeWork := map[string]any{
+		"@context": "https://schema.org",
+		"@type":    "CreativeWork",
+		"@id":      pageURL,
+		"url":      pageURL,
+		"name":     content.Title.plainText(),
+	}
+	if creativeWork["name"] == "" {
+		creativeWork["name"] = work.ID
+	}
+	if language != "default" {
+		creativeWork["inLanguage"] = language
+	}
+
+	// Works that are not translated are in the default language
+	contentLanguage := language
+	if _, ok := work.Content[language]; !ok {
+		contentLanguage = "default"
+	}
+	if found, paragraph := work.FirstParagraph(contentLanguage); found {
+		creativeWork["description"] = paragraph.Content.plainText()
+	}
+
+	if created, err := work.Metadata.CreationDate(); err == nil && created.Known() {
+		creativeWork["dateCreated"] = created.String()
+	}
+	if !work.BuiltAt.IsZero() {
+		creativeWork["dateModified"] = work.BuiltAt.Format(time.RFC3339)
+	}
+	if options.Author != "" {
+		creativeWork["author"] = map[string]any{"@type": "Person", "name": options.Author}
+	}
+
+	keywords := make([]string, 0)
+	for _, name := range work.Metadata.Localize(language).Tags {
+		if tag, ok := db.Tag(name); ok {
+			name = tag.Localize(language).Singular
+		}
+		keywords = append(keywords, name)
+	}
+	if len(keywords) > 0 {
+		creativeWork["keywords"] = keywords
+	}
+
+	if thumbnail := work.ThumbnailBlock(language); len(thumbnail.Thumbnails) > 0 || strings.HasPrefix(thumbnail.ContentType, "image/") {
+		creativeWork["thumbnailUrl"] = e.thumbnailURL(options, thumbnail)
+	}
+
+	images, videos := make([]map[string]any, 0), make([]map[string]any, 0)
+	for _, block := range content.Blocks {
+		if !block.Type.IsMedia() || block.DistSource == "" {
+			continue
+		}
+		media := block.AsMedia()
+		switch {
+		case strings.HasPrefix(media.ContentType, "image/"):
+			images = append(images, e.mediaObject(options, "ImageObject", media, creativeWork["name"].(string)))
+		case strings.HasPrefix(media.ContentType, "video/"):
+			video := e.mediaObject(options, "VideoObject", media, creativeWork["name"].(string))
+			if media.Duration > 0 {
+				video["duration"] = isoDuration(media.Duration)
+			}
+			if !work.BuiltAt.IsZero() {
+				video["uploadDate"] = work.BuiltAt.Format(time.RFC3339)
+			}
+			videos = append(videos, video)
+		}
+	}
+	if len(images) > 0 {
+		creativeWork["image"] = images
+	}
+	if len(videos) > 0 {
+		creativeWork["video"] = videos
+	}
+	return creativeWork
+}
+
+// mediaObject returns the schema.org object of the given type (ImageObject or VideoObject) that describes the media.
+func (e *SitemapExporter) mediaObject(options SitemapExporterOptions, schemaType string, media Media, workName string) map[string]any {
+	object := map[string]any{
+		"@type":          schemaType,
+		"contentUrl":     absoluteURL(options.SiteURL, mediaURL(options.MediaURL, media.DistSource)),
+		"encodingFormat": media.ContentType,
+		"name":           workName,
+	}
+	if media.Alt != "" {
+		object["name"] = media.Alt
+	}
+	if media.Caption != "" {
+		object["caption"] = media.Caption
+		object["description"] = media.Caption
+	}
+	if media.Dimensions.Width > 0 && media.Dimensions.Height > 0 {
+		object["width"] = media.Dimensions.Width
+		object["height"] = media.Dimensions.Height
+	}
+	if len(media.Thumbnails) > 0 {
+		object["thumbnailUrl"] = e.thumbnailURL(options, media)
+	}
+	return object
+}
+
+// thumbnailURL returns the URL of the largest thumbnail of the media, or of the media itself if it has no thumbnails.
+func (e *SitemapExporter) thumbnailURL(options SitemapExporterOptions, media Media) string {
+	path := media.DistSource
+	if len(media.Thumbnails) > 0 {
+		path = media.Thumbnails.Closest(math.MaxInt)
+	}
+	return absoluteURL(options.SiteURL, mediaURL(options.MediaURL, path))
+}
+
+// tagURL returns the URL of the page of the given tag in the given language, from the given template with <tag> and <language> placeholders.
+func tagURL(template string, language string, tag string) string {
+	return strings.NewReplacer("<tag>", url.PathEscape(tag), "<language>", language).Replace(template)
+}
+
+// isoDuration returns the ISO 8601 duration of the given number of seconds, for example PT1M30S.
+func isoDuration(seconds float64) string {
+	duration := time.Duration(math.Round(seconds)) * time.Second
+	hours, minutes, secs := int(duration.Hours()), int(duration.Minutes())%60, int(duration.Seconds())%60
+	result := "PT"
+	if hours > 0 {
+		result += fmt.Sprintf("%dH", hours)
+	}
+	if minutes > 0 {
+		result += fmt.Sprintf("%dM", minutes)
+	}
+	if secs > 0 || result == "PT" {
+		result += fmt.Sprintf("%dS", secs)
+	}
+	return result
+}
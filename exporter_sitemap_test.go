@@ -0,0 +1,28 @@
+package ortfodb
+
+import "testing"
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{seconds: 0, want: "PT0S"},
+		{seconds: 0.4, want: "PT0S"},
+		{seconds: 0.6, want: "PT1S"},
+		{seconds: 45, want: "PT45S"},
+		{seconds: 60, want: "PT1M"},
+		{seconds: 90, want: "PT1M30S"},
+		{seconds: 3600, want: "PT1H"},
+		{seconds: 3661, want: "PT1H1M1S"},
+		{seconds: 7205.5, want: "PT2H6S"},
+		{seconds: 90000, want: "PT25H"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := isoDuration(tt.seconds); got != tt.want {
+				t.Errorf("isoDuration(%v) = %q, want %q", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
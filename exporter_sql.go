@@ -52,8 +52,8 @@ func (e *SqlExporter) Export(ctx *RunContext, opts PluginOptions, work *Work) er
 		work.ID,
 		work.Content.Localize(options.Language).Title,
 		summary.Content.Markdown(),
-		work.Metadata.Started,
-		work.Metadata.Finished,
+		work.Metadata.Started.String(),
+		work.Metadata.Finished.String(),
 		strings.Join(work.Metadata.Tags, ","),
 		strings.Join(work.Metadata.MadeWith, ","),
 	)
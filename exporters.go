@@ -2,6 +2,8 @@ package ortfodb
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	ll "github.com/gwennlbh/label-logger-go"
 )
@@ -53,7 +55,7 @@ func (ctx *RunContext) ExporterOptions(exporter Plugin) (PluginOptions, error) {
 }
 
 func BuiltinExporters() (exporters []Exporter) {
-	plugins := BuiltinPlugins("exporters", &SqlExporter{}, &LocalizeExporter{})
+	plugins := BuiltinPlugins("exporters", &SqlExporter{}, &LocalizeExporter{}, &FeedsExporter{}, &SitemapExporter{})
 
 	for _, plugin := range plugins {
 		if exporter, ok := plugin.(Exporter); ok {
@@ -83,3 +85,14 @@ func (ctx *RunContext) FindExporter(name string) (Exporter, error) {
 		return nil, fmt.Errorf("plugin %q is not an exporter", name)
 	}
 }
+
+// writeExportedFile writes an exporter's output file, creating its directory if needed.
+func writeExportedFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("while creating directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, contents, 0o644); err != nil {
+		return fmt.Errorf("while writing %s: %w", path, err)
+	}
+	return nil
+}
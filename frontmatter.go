@@ -0,0 +1,547 @@
+package ortfodb
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// FrontMatterFormat is the language the front matter of a description file is written in.
+type FrontMatterFormat string
+
+const (
+	FrontMatterNone FrontMatterFormat = ""
+	FrontMatterYAML FrontMatterFormat = "yaml"
+	FrontMatterTOML FrontMatterFormat = "toml"
+	FrontMatterJSON FrontMatterFormat = "json"
+)
+
+// FrontMatter describes the front matter block found at the start of a description file.
+type FrontMatter struct {
+	Format FrontMatterFormat
+	// Raw contents of the front matter, without the --- or +++ delimiters.
+	Raw string
+	// Line number (starting at 1) in the description file of the first line of Raw.
+	StartLine int
+	// Problems that did not prevent the front matter from being decoded, such as likely misspelled keys.
+	Warnings []FrontMatterIssue
+	// Maps normalized top-level keys to the line they are declared on in the description file.
+	keyLines map[string]int
+}
+
+// KeyLine returns the line number in the description file on which the given top-level key is declared, or 0 if it is not declared.
+func (f FrontMatter) KeyLine(key string) int {
+	return f.keyLines[normalizeFrontMatterKey(key)]
+}
+
+// FrontMatterIssue is an error or warning about a description file's front matter.
+type FrontMatterIssue struct {
+	Format FrontMatterFormat
+	// Line number in the description file. 0 if unknown.
+	Line int
+	// Top-level key the issue is about, if any.
+	Key     string
+	Message string
+}
+
+func (i FrontMatterIssue) Error() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s front matter: %s", i.Format, i.Message)
+	}
+	return fmt.Sprintf("%s front matter, line %d: %s", i.Format, i.Line, i.Message)
+}
+
+// FrontMatterIssues groups all the errors found while decoding a front matter.
+type FrontMatterIssues []FrontMatterIssue
+
+func (issues FrontMatterIssues) Error() string {
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ParseFrontMatter parses the front matter of a description markdown file and returns the rest of the content (all except the front matter).
+// The front matter must be at the very start of the file, and can be written in:
+//
+//   - YAML, between two --- lines
+//   - TOML, between two +++ lines
+//   - JSON, as an object starting on the first line. Files that start with a brace but not with a valid JSON object have no front matter
+//
+// Keys are matched to Metadata's fields regardless of case, spaces, dashes and underscores.
+// Syntax errors and values that cannot be decoded are returned as a FrontMatterIssues error, with line numbers relative to the description file.
+func ParseFrontMatter[Metadata any](descriptionRaw string) (Metadata, FrontMatter, string, error) {
+	var metadata Metadata
+	frontMatter, markdownRaw, err := splitFrontMatter(descriptionRaw)
+	if err != nil || frontMatter.Format == FrontMatterNone {
+		return metadata, frontMatter, markdownRaw, err
+	}
+
+	values, err := frontMatter.decodeRaw()
+	if err != nil {
+		return metadata, frontMatter, markdownRaw, err
+	}
+
+	values = normalizeFrontMatterValue(values).(map[string]interface{})
+	for key, value := range values {
+		if strings.Contains(key, " ") {
+			values[strings.ReplaceAll(key, " ", "_")] = value
+			delete(values, key)
+		}
+	}
+
+	frontMatter.Warnings = frontMatter.unknownKeysWarnings(values, reflect.TypeOf(metadata))
+
+	if err := decodeFrontMatterValues(values, &metadata); err != nil {
+		return metadata, frontMatter, markdownRaw, locateDecodeErrors[Metadata](frontMatter, values, err)
+	}
+
+	return metadata, frontMatter, markdownRaw, nil
+}
+
+// ParseYAMLHeader parses the YAML header of a description markdown file and returns the rest of the content (all except the YAML header).
+//
+// Deprecated: Use ParseFrontMatter, which also supports TOML and JSON and reports errors.
+func ParseYAMLHeader[Metadata interface{}](descriptionRaw string) (Metadata, string) {
+	metadata, _, markdownRaw, _ := ParseFrontMatter[Metadata](descriptionRaw)
+	return metadata, markdownRaw
+}
+
+// splitFrontMatter separates the front matter from the markdown content.
+// Lines before the front matter are empty, they are kept in the markdown content.
+func splitFrontMatter(descriptionRaw string) (FrontMatter, string, error) {
+	lines := strings.Split(descriptionRaw, "\n")
+	for i, line := range lines {
+		// Replace tabs with four spaces
+		for strings.HasPrefix(line, "\t") {
+			line = strings.Repeat(" ", 4) + strings.TrimPrefix(line, "\t")
+		}
+		lines[i] = line
+	}
+
+	first := 0
+	for first < len(lines) && strings.TrimSpace(lines[first]) == "" {
+		first++
+	}
+	if first == len(lines) {
+		return FrontMatter{}, strings.Join(lines, "\n"), nil
+	}
+
+	before := strings.Join(lines[:first], "\n")
+	if first > 0 {
+		before += "\n"
+	}
+
+	var separator *regexp.Regexp
+	frontMatter := FrontMatter{StartLine: first + 2}
+	switch {
+	case regexp.MustCompile(PatternYAMLSeparator).MatchString(lines[first]):
+		frontMatter.Format = FrontMatterYAML
+		separator = regexp.MustCompile(PatternYAMLSeparator)
+	case regexp.MustCompile(PatternTOMLSeparator).MatchString(lines[first]):
+		frontMatter.Format = FrontMatterTOML
+		separator = regexp.MustCompile(PatternTOMLSeparator)
+	case strings.HasPrefix(strings.TrimSpace(lines[first]), "{"):
+		frontMatter.Format = FrontMatterJSON
+		frontMatter.StartLine = first + 1
+		return splitJSONFrontMatter(frontMatter, before, strings.Join(lines[first:], "\n"))
+	default:
+		return FrontMatter{}, strings.Join(lines, "\n"), nil
+	}
+
+	for end := first + 1; end < len(lines); end++ {
+		if separator.MatchString(lines[end]) {
+			frontMatter.Raw = strings.Join(lines[first+1:end], "\n") + "\n"
+			return frontMatter, before + strings.Join(lines[end+1:], "\n"), nil
+		}
+	}
+
+	return frontMatter, strings.Join(lines, "\n"), FrontMatterIssues{{
+		Format:  frontMatter.Format,
+		Line:    first + 1,
+		Message: fmt.Sprintf("front matter is never closed: add a %s line after it", strings.TrimSpace(lines[first])),
+	}}
+}
+
+// splitJSONFrontMatter reads the JSON object at the start of text, the rest is markdown content.
+// Text that does not start with a whole JSON object, such as a {#name} block marker or a paragraph starting with a brace, has no front matter.
+func splitJSONFrontMatter(frontMatter FrontMatter, before string, text string) (FrontMatter, string, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	var object json.RawMessage
+	if err := decoder.Decode(&object); err != nil {
+		return FrontMatter{}, before + text, nil
+	}
+
+	end := int(decoder.InputOffset())
+	rest := text[end:]
+	newline := strings.Index(rest, "\n")
+	if newline == -1 {
+		newline = len(rest)
+	}
+	// The object has to be alone on its last line, {"a": 1} and {"b": 2} is a paragraph
+	if strings.TrimSpace(rest[:newline]) != "" {
+		return FrontMatter{}, before + text, nil
+	}
+
+	frontMatter.Raw = text[:end]
+	rest = strings.TrimPrefix(rest[newline:], "\n")
+	return frontMatter, before + rest, nil
+}
+
+// lineAtOffset returns the line number in the description file of the byte at the given offset in text, which starts at the front matter's first line.
+func (f FrontMatter) lineAtOffset(text string, offset int64) int {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+	return f.StartLine + strings.Count(text[:offset], "\n")
+}
+
+// decodeRaw decodes the raw front matter into a map, and records on which line each top-level key is declared.
+func (f *FrontMatter) decodeRaw() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	issue := FrontMatterIssue{Format: f.Format, Line: f.StartLine}
+
+	switch f.Format {
+	case FrontMatterYAML:
+		if err := yaml.Unmarshal([]byte(f.Raw), &values); err != nil {
+			issue.Message = strings.TrimPrefix(err.Error(), "yaml: ")
+			if groups := regexp.MustCompile(`line (\d+): (.+)`).FindStringSubmatch(err.Error()); groups != nil {
+				line, _ := strconv.Atoi(groups[1])
+				issue.Line = f.StartLine + line - 1
+				issue.Message = groups[2]
+			}
+			return nil, FrontMatterIssues{issue}
+		}
+		f.keyLines = yamlKeyLines(f.Raw, f.StartLine)
+
+	case FrontMatterTOML:
+		if _, err := toml.Decode(f.Raw, &values); err != nil {
+			issue.Message = err.Error()
+			var parseError toml.ParseError
+			if errors.As(err, &parseError) {
+				issue.Line = f.StartLine + parseError.Position.Line - 1
+				issue.Message = regexp.MustCompile(`^toml: line \d+( \(last key "[^"]*"\))?: `).ReplaceAllString(parseError.Error(), "")
+			}
+			return nil, FrontMatterIssues{issue}
+		}
+		f.keyLines = tomlKeyLines(f.Raw, f.StartLine)
+
+	case FrontMatterJSON:
+		if err := json.Unmarshal([]byte(f.Raw), &values); err != nil {
+			issue.Message = err.Error()
+			var typeError *json.UnmarshalTypeError
+			if errors.As(err, &typeError) {
+				issue.Line = f.lineAtOffset(f.Raw, typeError.Offset)
+				issue.Message = "front matter must be a JSON object"
+			}
+			return nil, FrontMatterIssues{issue}
+		}
+		f.keyLines = f.jsonKeyLines()
+	}
+
+	return values, nil
+}
+
+// yamlKeyLines maps top-level keys of the given YAML document to their line numbers, offset by startLine.
+func yamlKeyLines(raw string, startLine int) map[string]int {
+	lines := make(map[string]int)
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(raw), &document); err != nil || len(document.Content) == 0 {
+		return lines
+	}
+	mapping := document.Content[0]
+	if mapping.Kind != yamlv3.MappingNode {
+		return lines
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		lines[normalizeFrontMatterKey(mapping.Content[i].Value)] = startLine + mapping.Content[i].Line - 1
+	}
+	return lines
+}
+
+// tomlKeyLines maps top-level keys (and table names) of the given TOML document to their line numbers, offset by startLine.
+func tomlKeyLines(raw string, startLine int) map[string]int {
+	pattern := regexp.MustCompile(`^\s*(\[{0,2})\s*(?:"([^"]*)"|'([^']*)'|([A-Za-z0-9_-]+))\s*[=.\]]`)
+	lines := make(map[string]int)
+	inTable := false
+	for i, line := range strings.Split(raw, "\n") {
+		groups := pattern.FindStringSubmatch(line)
+		if groups == nil {
+			continue
+		}
+		isTableHeader := groups[1] != ""
+		if !isTableHeader && inTable {
+			continue
+		}
+		inTable = inTable || isTableHeader
+		key := normalizeFrontMatterKey(groups[2] + groups[3] + groups[4])
+		if _, ok := lines[key]; !ok {
+			lines[key] = startLine + i
+		}
+	}
+	return lines
+}
+
+// jsonKeyLines maps keys of the front matter's JSON object to their line numbers.
+func (f FrontMatter) jsonKeyLines() map[string]int {
+	lines := make(map[string]int)
+	decoder := json.NewDecoder(strings.NewReader(f.Raw))
+	depth := 0
+	expectingKey := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return lines
+		}
+		switch token := token.(type) {
+		case json.Delim:
+			if token == '{' || token == '[' {
+				depth++
+				expectingKey = depth == 1
+			} else {
+				depth--
+				expectingKey = depth == 1
+			}
+		case string:
+			if depth == 1 && expectingKey {
+				lines[normalizeFrontMatterKey(token)] = f.lineAtOffset(f.Raw, decoder.InputOffset())
+				expectingKey = false
+			} else if depth == 1 {
+				expectingKey = true
+			}
+		default:
+			if depth == 1 {
+				expectingKey = true
+			}
+		}
+	}
+}
+
+// normalizeFrontMatterValue converts values produced by the YAML and TOML decoders into values that can be decoded by mapstructure and marshaled to JSON.
+func normalizeFrontMatterValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[fmt.Sprint(key)] = normalizeFrontMatterValue(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = normalizeFrontMatterValue(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeFrontMatterValue(item)
+		}
+		return value
+	case []map[string]interface{}:
+		normalized := make([]interface{}, len(value))
+		for i, item := range value {
+			normalized[i] = normalizeFrontMatterValue(item)
+		}
+		return normalized
+	case time.Time:
+		// TOML has native dates, but metadata expects them as strings, just like YAML dates.
+		switch value.Location().String() {
+		case "date-local":
+			return value.Format(time.DateOnly)
+		case "datetime-local":
+			return value.Format("2006-01-02T15:04:05")
+		case "time-local":
+			return value.Format(time.TimeOnly)
+		}
+		return value.Format(time.RFC3339)
+	}
+	return value
+}
+
+// normalizeFrontMatterKey makes "made with", "made_with", "made-with" and "MadeWith" equivalent.
+func normalizeFrontMatterKey(key string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// textUnmarshalerHook decodes strings and numbers into values of types that implement encoding.TextUnmarshaler, such as Date.
+func textUnmarshalerHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	result := reflect.New(to)
+	unmarshaler, ok := result.Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return data, nil
+	}
+	switch from.Kind() {
+	case reflect.String, reflect.Int, reflect.Int64, reflect.Float64:
+	default:
+		return data, nil
+	}
+	if err := unmarshaler.UnmarshalText([]byte(fmt.Sprint(data))); err != nil {
+		return nil, err
+	}
+	return result.Elem().Interface(), nil
+}
+
+func decodeFrontMatterValues(values map[string]interface{}, result any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           result,
+		WeaklyTypedInput: true,
+		DecodeHook:       textUnmarshalerHook,
+		MatchName: func(mapKey, fieldName string) bool {
+			return normalizeFrontMatterKey(mapKey) == normalizeFrontMatterKey(fieldName)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(values)
+}
+
+// locateDecodeErrors decodes each top-level key separately to find out which ones could not be decoded, and where they are declared.
+func locateDecodeErrors[Metadata any](f FrontMatter, values map[string]interface{}, err error) FrontMatterIssues {
+	var issues FrontMatterIssues
+	for key, value := range values {
+		var probe Metadata
+		keyErr := decodeFrontMatterValues(map[string]interface{}{key: value}, &probe)
+		if keyErr == nil {
+			continue
+		}
+		message := keyErr.Error()
+		var decodeError *mapstructure.Error
+		if errors.As(keyErr, &decodeError) {
+			message = strings.Join(decodeError.Errors, "; ")
+		}
+		issues = append(issues, FrontMatterIssue{
+			Format:  f.Format,
+			Line:    f.KeyLine(key),
+			Key:     key,
+			Message: message,
+		})
+	}
+
+	if len(issues) == 0 {
+		return FrontMatterIssues{{Format: f.Format, Line: f.StartLine, Message: err.Error()}}
+	}
+
+	sortFrontMatterIssues(issues)
+	return issues
+}
+
+// unknownKeysWarnings warns about top-level keys that do not correspond to any field of metadataType.
+// When metadataType collects unknown keys (with a ",remain" mapstructure tag), only keys that look like a misspelled field are reported.
+func (f FrontMatter) unknownKeysWarnings(values map[string]interface{}, metadataType reflect.Type) []FrontMatterIssue {
+	if metadataType == nil || metadataType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	knownKeys := make([]string, 0, metadataType.NumField())
+	collectsUnknownKeys := false
+	for i := 0; i < metadataType.NumField(); i++ {
+		field := metadataType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if strings.Contains(field.Tag.Get("mapstructure"), "remain") {
+			collectsUnknownKeys = true
+			continue
+		}
+		yamlName := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if yamlName == "-" {
+			continue
+		}
+		knownKeys = append(knownKeys, normalizeFrontMatterKey(field.Name))
+		for _, name := range []string{yamlName, strings.Split(field.Tag.Get("json"), ",")[0]} {
+			if name != "" {
+				knownKeys = append(knownKeys, normalizeFrontMatterKey(name))
+			}
+		}
+	}
+
+	var warnings []FrontMatterIssue
+	for key := range values {
+		normalizedKey := normalizeFrontMatterKey(key)
+		if stringInSlice(knownKeys, normalizedKey) {
+			continue
+		}
+
+		suggestion := ""
+		for _, known := range knownKeys {
+			maxDistance := 1
+			if len(normalizedKey) >= 6 {
+				maxDistance = 2
+			}
+			if levenshtein(normalizedKey, known) <= maxDistance {
+				suggestion = known
+				break
+			}
+		}
+
+		displayKey := strings.ReplaceAll(key, "_", " ")
+		switch {
+		case suggestion != "" && collectsUnknownKeys:
+			warnings = append(warnings, FrontMatterIssue{
+				Format:  f.Format,
+				Line:    f.KeyLine(key),
+				Key:     displayKey,
+				Message: fmt.Sprintf("unknown key %q, did you mean %q? it will be kept as additional metadata", displayKey, f.fieldDisplayName(metadataType, suggestion)),
+			})
+		case suggestion != "":
+			warnings = append(warnings, FrontMatterIssue{
+				Format:  f.Format,
+				Line:    f.KeyLine(key),
+				Key:     displayKey,
+				Message: fmt.Sprintf("unknown key %q, did you mean %q?", displayKey, f.fieldDisplayName(metadataType, suggestion)),
+			})
+		case !collectsUnknownKeys:
+			warnings = append(warnings, FrontMatterIssue{
+				Format:  f.Format,
+				Line:    f.KeyLine(key),
+				Key:     displayKey,
+				Message: fmt.Sprintf("unknown key %q will be ignored", displayKey),
+			})
+		}
+	}
+
+	sortFrontMatterIssues(warnings)
+	return warnings
+}
+
+// fieldDisplayName returns how the field matching normalizedKey should be written in the front matter.
+func (f FrontMatter) fieldDisplayName(metadataType reflect.Type, normalizedKey string) string {
+	for i := 0; i < metadataType.NumField(); i++ {
+		field := metadataType.Field(i)
+		yamlName := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+		if normalizedKey != normalizeFrontMatterKey(field.Name) && normalizedKey != normalizeFrontMatterKey(yamlName) && normalizedKey != normalizeFrontMatterKey(jsonName) {
+			continue
+		}
+		switch {
+		case f.Format == FrontMatterJSON && jsonName != "":
+			return jsonName
+		case yamlName != "":
+			return yamlName
+		}
+		return strings.ToLower(field.Name)
+	}
+	return normalizedKey
+}
+
+func sortFrontMatterIssues(issues []FrontMatterIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Key < issues[j].Key
+	})
+}
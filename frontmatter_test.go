@@ -0,0 +1,115 @@
+package ortfodb
+
+import "testing"
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name          string
+		description   string
+		wantFormat    FrontMatterFormat
+		wantRaw       string
+		wantStartLine int
+		wantMarkdown  string
+		wantErr       bool
+	}{
+		{
+			name:         "no front matter",
+			description:  "# Hello\n\nWorld\n",
+			wantMarkdown: "# Hello\n\nWorld\n",
+		},
+		{
+			name:          "yaml",
+			description:   "---\nwip: true\n---\n# Hello\n",
+			wantFormat:    FrontMatterYAML,
+			wantRaw:       "wip: true\n",
+			wantStartLine: 2,
+			wantMarkdown:  "# Hello\n",
+		},
+		{
+			name:          "yaml after empty lines",
+			description:   "\n\n---\nwip: true\n---\n# Hello\n",
+			wantFormat:    FrontMatterYAML,
+			wantRaw:       "wip: true\n",
+			wantStartLine: 4,
+			wantMarkdown:  "\n\n# Hello\n",
+		},
+		{
+			name:         "horizontal rule after content",
+			description:  "# Hello\n\n---\n\nWorld\n",
+			wantMarkdown: "# Hello\n\n---\n\nWorld\n",
+		},
+		{
+			name:          "toml",
+			description:   "+++\nwip = true\n+++\n# Hello\n",
+			wantFormat:    FrontMatterTOML,
+			wantRaw:       "wip = true\n",
+			wantStartLine: 2,
+			wantMarkdown:  "# Hello\n",
+		},
+		{
+			name:        "unclosed yaml",
+			description: "---\nwip: true\n# Hello\n",
+			wantFormat:  FrontMatterYAML,
+			wantErr:     true,
+		},
+		{
+			name:          "json",
+			description:   "{\n  \"wip\": true\n}\n# Hello\n",
+			wantFormat:    FrontMatterJSON,
+			wantRaw:       "{\n  \"wip\": true\n}",
+			wantStartLine: 1,
+			wantMarkdown:  "# Hello\n",
+		},
+		{
+			name:          "json on a single line",
+			description:   "{\"wip\": true}\n\n# Hello\n",
+			wantFormat:    FrontMatterJSON,
+			wantRaw:       "{\"wip\": true}",
+			wantStartLine: 1,
+			wantMarkdown:  "\n# Hello\n",
+		},
+		{
+			name:         "block marker",
+			description:  "{#intro}\nHello\n",
+			wantMarkdown: "{#intro}\nHello\n",
+		},
+		{
+			name:         "paragraph starting with a brace",
+			description:  "{curly} braces\n",
+			wantMarkdown: "{curly} braces\n",
+		},
+		{
+			name:         "paragraph starting with a json object",
+			description:  "{\"a\": 1} is an object\n",
+			wantMarkdown: "{\"a\": 1} is an object\n",
+		},
+		{
+			name:         "unclosed json object",
+			description:  "{\n  \"wip\": true\n# Hello\n",
+			wantMarkdown: "{\n  \"wip\": true\n# Hello\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frontMatter, markdown, err := splitFrontMatter(tt.description)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitFrontMatter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if frontMatter.Format != tt.wantFormat {
+				t.Errorf("splitFrontMatter() format = %q, want %q", frontMatter.Format, tt.wantFormat)
+			}
+			if tt.wantErr {
+				return
+			}
+			if frontMatter.Raw != tt.wantRaw {
+				t.Errorf("splitFrontMatter() raw = %q, want %q", frontMatter.Raw, tt.wantRaw)
+			}
+			if frontMatter.Format != FrontMatterNone && frontMatter.StartLine != tt.wantStartLine {
+				t.Errorf("splitFrontMatter() start line = %d, want %d", frontMatter.StartLine, tt.wantStartLine)
+			}
+			if markdown != tt.wantMarkdown {
+				t.Errorf("splitFrontMatter() markdown = %q, want %q", markdown, tt.wantMarkdown)
+			}
+		})
+	}
+}
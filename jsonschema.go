@@ -7,7 +7,7 @@ import (
 	"github.com/invopop/jsonschema"
 )
 
-var AvailableJSONSchemas = []string{"configuration", "database", "tags", "technologies", "exporter", "importer"}
+var AvailableJSONSchemas = []string{"configuration", "database", "tags", "technologies", "collections", "exporter", "importer"}
 
 var yamlReflector = jsonschema.Reflector{
 	FieldNameTag: "yaml",
@@ -36,7 +36,18 @@ func ConfigurationJSONSchema() *jsonschema.Schema {
 }
 
 func DatabaseJSONSchema() *jsonschema.Schema {
-	return makeJSONSchema(&Database{}, false)
+	schema := makeJSONSchema(&Database{}, false)
+	// The repositories are not part of works, see Database.MarshalJSON
+	repositories := makeJSONSchema(&DatabaseRepositories{}, false)
+	for name, definition := range repositories.Definitions {
+		schema.Definitions[name] = definition
+	}
+	schema.Definitions["Database"].Properties = jsonschema.NewProperties()
+	schema.Definitions["Database"].Properties.Set(DatabaseMetaKey, &jsonschema.Schema{
+		Ref:         "#/$defs/DatabaseRepositories",
+		Description: "Tags and technologies repositories, and collections.",
+	})
+	return schema
 }
 
 type tags []Tag
@@ -51,6 +62,12 @@ func TechnologiesRepositoryJSONSchema() *jsonschema.Schema {
 	return makeJSONSchema(&technologies{}, true)
 }
 
+type collections []Collection
+
+func CollectionsRepositoryJSONSchema() *jsonschema.Schema {
+	return makeJSONSchema(&collections{}, true)
+}
+
 func ExporterManifestJSONSchema() *jsonschema.Schema {
 	schema := makeJSONSchema(&ExporterManifest{}, true)
 	setSchemaId(schema, "exporter")
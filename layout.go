@@ -2,7 +2,9 @@ package ortfodb
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	ll "github.com/gwennlbh/label-logger-go"
 )
@@ -82,60 +84,424 @@ func (layout Layout) BlockIDs() (blockIDs []string) {
 	return blockIDs
 }
 
+// DefaultLayoutGrid is the key of the default layout (the one declared with "layout") in LocalizedContent.Grids. It can't be used as a breakpoint name.
+const DefaultLayoutGrid = "default"
+
+// LayoutGrid is a layout where every block is placed once on a grid, along with how many columns and rows it spans, instead of being repeated like in a normalized Layout.
+type LayoutGrid struct {
+	Columns int          `json:"columns"`
+	Rows    int          `json:"rows"`
+	Items   []LayoutItem `json:"items"`
+}
+
+// LayoutItem is a content block placed on a LayoutGrid. Empty cells have no LayoutItem.
+type LayoutItem struct {
+	// ID of the content block.
+	Block string `json:"block"`
+	// Row and column the block starts at, starting from 1 (like CSS grid lines).
+	Row        int `json:"row"`
+	Column     int `json:"column"`
+	RowSpan    int `json:"rowSpan"`
+	ColumnSpan int `json:"columnSpan"`
+	// One of start, center, end or stretch. Empty if not specified.
+	HorizontalAlign string `json:"horizontalAlign"`
+	// One of start, center, end or stretch. Empty if not specified.
+	VerticalAlign string `json:"verticalAlign"`
+	// Width divided by height that the block should have. 0 if not specified.
+	AspectRatio float64 `json:"aspectRatio"`
+}
+
+// layoutCellDeclaration is a cell of a layout as declared in the front matter, with its block reference resolved.
+type layoutCellDeclaration struct {
+	// Empty for empty cells.
+	block           string
+	columns         int
+	rows            int
+	horizontalAlign string
+	verticalAlign   string
+	aspectRatio     float64
+}
+
+// parseLayoutCell parses a cell of a user-provided layout: a block reference, nil for an empty cell, or an object with a block reference and its settings:
+//
+//	{block: m1, columns: 2, rows: 2, align: center, vertical align: top, aspect ratio: 16/9}
+func parseLayoutCell(cell interface{}, language string, blocks []ContentBlock) (layoutCellDeclaration, error) {
+	declaration := layoutCellDeclaration{columns: 1, rows: 1}
+	switch cell := cell.(type) {
+	case nil:
+		ll.Debug("encountered nil value in layout line, treating as empty cell")
+		return declaration, nil
+	case string:
+		id, err := ResolveBlockID(blocks, language, cell)
+		if err != nil {
+			return declaration, fmt.Errorf("while resolving block reference %q to ID: %w", cell, err)
+		}
+		declaration.block = id
+		return declaration, nil
+	case map[string]interface{}:
+		for key, value := range cell {
+			var err error
+			switch normalizeFrontMatterKey(key) {
+			case "block":
+				if value == nil {
+					continue
+				}
+				declaration.block, err = ResolveBlockID(blocks, language, fmt.Sprint(value))
+				if err != nil {
+					return declaration, fmt.Errorf("while resolving block reference %q to ID: %w", value, err)
+				}
+			case "columns":
+				declaration.columns, err = layoutCellSpan(value)
+			case "rows":
+				declaration.rows, err = layoutCellSpan(value)
+			case "align":
+				declaration.horizontalAlign, err = layoutCellAlignment(value, map[string]string{"left": "start", "right": "end"})
+			case "verticalalign":
+				declaration.verticalAlign, err = layoutCellAlignment(value, map[string]string{"top": "start", "bottom": "end"})
+			case "aspectratio":
+				declaration.aspectRatio, err = parseAspectRatio(fmt.Sprint(value))
+			default:
+				err = fmt.Errorf("unknown setting, use one of block, columns, rows, align, vertical align or aspect ratio")
+			}
+			if err != nil {
+				return declaration, fmt.Errorf("invalid layout cell %s: %w", key, err)
+			}
+		}
+		return declaration, nil
+	}
+	return declaration, fmt.Errorf("invalid layout cell %#v: use a block reference, an empty value, or an object with a block key", cell)
+}
+
+func layoutCellSpan(value interface{}) (int, error) {
+	span, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil || span < 1 {
+		return 0, fmt.Errorf("%v is not a positive integer", value)
+	}
+	return span, nil
+}
+
+// layoutCellAlignment validates an alignment setting and converts it to a CSS alignment value. aliases maps other accepted values to CSS values.
+func layoutCellAlignment(value interface{}, aliases map[string]string) (string, error) {
+	alignment := strings.ToLower(fmt.Sprint(value))
+	if alias, ok := aliases[alignment]; ok {
+		return alias, nil
+	}
+	if !stringInSlice([]string{"start", "center", "end", "stretch"}, alignment) {
+		return "", fmt.Errorf("%q is not one of start, center, end, stretch, %s", alignment, strings.Join(sortedKeys(aliases), ", "))
+	}
+	return alignment, nil
+}
+
+// parseAspectRatio parses aspect ratios written as "16/9", "16:9" or "1.77".
+func parseAspectRatio(raw string) (float64, error) {
+	for _, separator := range []string{"/", ":"} {
+		if width, height, found := strings.Cut(raw, separator); found {
+			w, errW := strconv.ParseFloat(strings.TrimSpace(width), 64)
+			h, errH := strconv.ParseFloat(strings.TrimSpace(height), 64)
+			if errW != nil || errH != nil || w <= 0 || h <= 0 {
+				return 0, fmt.Errorf("%q is not a valid aspect ratio", raw)
+			}
+			return w / h, nil
+		}
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || ratio <= 0 {
+		return 0, fmt.Errorf("%q is not a valid aspect ratio", raw)
+	}
+	return ratio, nil
+}
+
+// newLayoutGrid places the declared cells on a grid.
+// The width of a row is the sum of the column spans of its cells, including the ones of cells from previous rows that span into it. The grid has as many columns as the least common multiple of the rows' widths, and the column spans of each row are scaled so that it fills the grid.
+// Blocks repeated on adjacent cells, such as p1 in [p1, p1, p2], are placed once, spanning all of these cells.
+func newLayoutGrid(rows [][]layoutCellDeclaration) (LayoutGrid, error) {
+	grid := LayoutGrid{Rows: len(rows), Items: make([]LayoutItem, 0)}
+	if len(rows) == 0 {
+		return grid, nil
+	}
+
+	// Compute the width of every row
+	widths := make([]int, len(rows))
+	for r, row := range rows {
+		for _, cell := range row {
+			if r+cell.rows > len(rows) {
+				return grid, fmt.Errorf("row %d: a cell spans %d rows, but there are only %d rows left in the layout", r+1, cell.rows, len(rows)-r)
+			}
+			for spanned := r; spanned < r+cell.rows; spanned++ {
+				widths[spanned] += cell.columns
+			}
+		}
+	}
+	for r, width := range widths {
+		if width == 0 {
+			return grid, fmt.Errorf("row %d is empty", r+1)
+		}
+	}
+	grid.Columns = lcm(widths...)
+
+	// Place cells, left to right, skipping the columns taken by cells from previous rows
+	occupied := make([][]bool, len(rows))
+	for r := range occupied {
+		occupied[r] = make([]bool, grid.Columns)
+	}
+	for r, row := range rows {
+		factor := grid.Columns / widths[r]
+		column := 0
+		for _, cell := range row {
+			for column < grid.Columns && occupied[r][column] {
+				column++
+			}
+			span := cell.columns * factor
+			if column+span > grid.Columns || slices.Contains(occupied[r][column:column+span], true) {
+				return grid, fmt.Errorf("row %d: cells do not line up with cells spanning from previous rows, give cells column spans so that every row has the same width", r+1)
+			}
+			for spanned := r; spanned < r+cell.rows; spanned++ {
+				for c := column; c < column+span; c++ {
+					occupied[spanned][c] = true
+				}
+			}
+			if cell.block != "" {
+				grid.Items = append(grid.Items, LayoutItem{
+					Block:           cell.block,
+					Row:             r + 1,
+					Column:          column + 1,
+					RowSpan:         cell.rows,
+					ColumnSpan:      span,
+					HorizontalAlign: cell.horizontalAlign,
+					VerticalAlign:   cell.verticalAlign,
+					AspectRatio:     cell.aspectRatio,
+				})
+			}
+			column += span
+		}
+		if slices.Contains(occupied[r], false) {
+			return grid, fmt.Errorf("row %d: cells do not line up with cells spanning from previous rows, give cells column spans so that every row has the same width", r+1)
+		}
+	}
+	grid.Items = mergeLayoutItems(grid.Items)
+	return grid, nil
+}
+
+// mergeLayoutItems merges items of the same block that are next to each other into a single item, first on the same rows, then on the same columns. Items are only merged if they form a rectangle and have the same settings.
+func mergeLayoutItems(items []LayoutItem) []LayoutItem {
+	mergeable := func(a, b LayoutItem) bool {
+		return a.Block == b.Block && a.HorizontalAlign == b.HorizontalAlign && a.VerticalAlign == b.VerticalAlign && a.AspectRatio == b.AspectRatio
+	}
+	merge := func(items []LayoutItem, adjacent func(a, b LayoutItem) bool, extend func(a *LayoutItem, b LayoutItem)) []LayoutItem {
+		merged := make([]LayoutItem, 0, len(items))
+		for _, item := range items {
+			i := slices.IndexFunc(merged, func(candidate LayoutItem) bool { return mergeable(candidate, item) && adjacent(candidate, item) })
+			if i == -1 {
+				merged = append(merged, item)
+			} else {
+				extend(&merged[i], item)
+			}
+		}
+		return merged
+	}
+
+	items = merge(items,
+		func(a, b LayoutItem) bool {
+			return a.Row == b.Row && a.RowSpan == b.RowSpan && a.Column+a.ColumnSpan == b.Column
+		},
+		func(a *LayoutItem, b LayoutItem) { a.ColumnSpan += b.ColumnSpan },
+	)
+	return merge(items,
+		func(a, b LayoutItem) bool {
+			return a.Column == b.Column && a.ColumnSpan == b.ColumnSpan && a.Row+a.RowSpan == b.Row
+		},
+		func(a *LayoutItem, b LayoutItem) { a.RowSpan += b.RowSpan },
+	)
+}
+
+// Layout returns the grid as a normalized Layout, where blocks are repeated on every cell they span.
+func (grid LayoutGrid) Layout() Layout {
+	layout := make(Layout, grid.Rows)
+	for r := range layout {
+		layout[r] = make([]LayoutCell, grid.Columns)
+		for c := range layout[r] {
+			layout[r][c] = LayoutCell(EmptyLayoutCell)
+		}
+	}
+	for _, item := range grid.Items {
+		for r := item.Row - 1; r < item.Row-1+item.RowSpan; r++ {
+			for c := item.Column - 1; c < item.Column-1+item.ColumnSpan; c++ {
+				layout[r][c] = LayoutCell(item.Block)
+			}
+		}
+	}
+	return layout
+}
+
+// GridTemplateAreas returns the value of the grid-template-areas CSS property for this grid.
+// Areas are named after the block IDs, prefixed with an underscore, since block IDs are not always valid CSS identifiers (see GridAreaName). Empty cells are represented by a dot.
+func (grid LayoutGrid) GridTemplateAreas() string {
+	rows := make([]string, 0, grid.Rows)
+	for _, row := range grid.Layout() {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			if string(cell) == EmptyLayoutCell {
+				cells = append(cells, ".")
+			} else {
+				cells = append(cells, GridAreaName(string(cell)))
+			}
+		}
+		rows = append(rows, fmt.Sprintf("%q", strings.Join(cells, " ")))
+	}
+	return strings.Join(rows, " ")
+}
+
+// GridAreaName returns the name of the CSS grid area of a block in GridTemplateAreas.
+func GridAreaName(blockID string) string {
+	return "_" + blockID
+}
+
 // ResolveLayout returns a layout, given the parsed description.
 func ResolveLayout(metadata WorkMetadata, language string, blocks []ContentBlock) (Layout, error) {
+	grid, err := ResolveLayoutGrid(metadata, language, blocks)
+	return grid.Layout(), err
+}
+
+// ResolveLayoutGrid returns the layout as a LayoutGrid, given the parsed description.
+func ResolveLayoutGrid(metadata WorkMetadata, language string, blocks []ContentBlock) (LayoutGrid, error) {
 	ll.Debug("Resolving layout from metadata %#v", metadata)
-	layout := make(Layout, 0)
-	userProvided := metadata.AdditionalMetadata["layout"]
+	return resolveUserLayout(metadata.Localize(language).AdditionalMetadata["layout"], language, blocks)
+}
+
+// ResolveLayouts returns the layout of every breakpoint, given the parsed description. See ResolveLayoutGrids.
+func ResolveLayouts(metadata WorkMetadata, language string, blocks []ContentBlock, breakpoints []string) (map[string]Layout, error) {
+	grids, err := ResolveLayoutGrids(metadata, language, blocks, breakpoints)
+	layouts := make(map[string]Layout, len(grids))
+	for breakpoint, grid := range grids {
+		layouts[breakpoint] = grid.Layout()
+	}
+	return layouts, err
+}
+
+// ResolveLayoutGrids returns the layout of every breakpoint, given the parsed description. Breakpoint layouts are declared with the "layouts" metadata, which maps breakpoint names to either a layout, or the name of another breakpoint to use the same layout.
+// breakpoints lists the known breakpoints, from the smallest screen to the largest. Every one of them gets a layout: those that are not declared use the layout of the closest smaller declared breakpoint, or else the default layout (see ResolveLayoutGrid).
+// If breakpoints is empty, any breakpoint name can be declared.
+func ResolveLayoutGrids(metadata WorkMetadata, language string, blocks []ContentBlock, breakpoints []string) (map[string]LayoutGrid, error) {
+	grids := make(map[string]LayoutGrid)
+	declared := make(map[string]interface{})
+	if userProvided := metadata.Localize(language).AdditionalMetadata["layouts"]; userProvided != nil {
+		var ok bool
+		declared, ok = userProvided.(map[string]interface{})
+		if !ok {
+			return grids, fmt.Errorf("layouts must map breakpoint names to layouts, use layout to declare a single layout")
+		}
+	}
+
+	names := sortedKeys(declared)
+	for _, name := range names {
+		if name == DefaultLayoutGrid {
+			return grids, fmt.Errorf("%q can't be used as a breakpoint name, use layout to declare the default layout", DefaultLayoutGrid)
+		}
+		if len(breakpoints) > 0 && !stringInSlice(breakpoints, name) {
+			return grids, fmt.Errorf("unknown breakpoint %q, available breakpoints are %s", name, strings.Join(breakpoints, ", "))
+		}
+	}
+
+	var resolve func(breakpoint string, chain []string) (LayoutGrid, error)
+	resolve = func(breakpoint string, chain []string) (LayoutGrid, error) {
+		if grid, ok := grids[breakpoint]; ok {
+			return grid, nil
+		}
+		if stringInSlice(chain, breakpoint) {
+			return LayoutGrid{}, fmt.Errorf("breakpoint layouts refer to each other in a loop (%s -> %s)", strings.Join(chain, " -> "), breakpoint)
+		}
+		chain = append(append([]string{}, chain...), breakpoint)
+
+		var grid LayoutGrid
+		var err error
+		switch value := declared[breakpoint].(type) {
+		case nil:
+			grid, err = ResolveLayoutGrid(metadata, language, blocks)
+			for i := slices.Index(breakpoints, breakpoint) - 1; i >= 0; i-- {
+				if declared[breakpoints[i]] != nil {
+					grid, err = resolve(breakpoints[i], chain)
+					break
+				}
+			}
+		case string:
+			if declared[value] == nil && !stringInSlice(breakpoints, value) {
+				return LayoutGrid{}, fmt.Errorf("%s layout refers to %q, which is not a breakpoint", breakpoint, value)
+			}
+			grid, err = resolve(value, chain)
+		case []interface{}:
+			grid, err = resolveUserLayout(value, language, blocks)
+			if err != nil {
+				err = fmt.Errorf("while resolving %s layout: %w", breakpoint, err)
+			}
+		default:
+			err = fmt.Errorf("%s layout must be a layout or the name of another breakpoint", breakpoint)
+		}
+		if err != nil {
+			return LayoutGrid{}, err
+		}
+		grids[breakpoint] = grid
+		return grid, nil
+	}
+
+	for _, breakpoint := range append(names, breakpoints...) {
+		if _, err := resolve(breakpoint, nil); err != nil {
+			return grids, err
+		}
+	}
+	return grids, nil
+}
+
+// resolveUserLayout converts a layout as declared in the front matter (refs to blocks) to a LayoutGrid (block IDs). If userProvided is empty, every block is on its own row.
+func resolveUserLayout(userProvided interface{}, language string, blocks []ContentBlock) (LayoutGrid, error) {
+	rows := make([][]layoutCellDeclaration, 0)
 	// Handle case where the layout is explicitly specified.
 	if userProvided != "" && userProvided != nil {
 		// User-provided layout uses block types and indices to refer to blocks. We need to convert those to block IDs.
 		if _, ok := userProvided.([]interface{}); ok {
 			for _, line := range userProvided.([]interface{}) {
-				layoutLine := make([]LayoutCell, 0)
 				ll.Debug("processing layout line %#v", line)
-				switch line := line.(type) {
-				case string:
-					cell, err := ResolveBlockID(blocks, language, line)
-					if err != nil {
-						return layout, fmt.Errorf("while resolving block reference %q to ID: %w", line, err)
-					}
+				cells, ok := line.([]interface{})
+				if !ok {
+					// A single cell on its own line
+					cells = []interface{}{line}
+				}
 
-					layoutLine = append(layoutLine, LayoutCell(cell))
-				case nil:
-					ll.Debug("encountered nil value in layout single line, treating as empty cell")
-					layoutLine = append(layoutLine, LayoutCell(EmptyLayoutCell))
-				case []interface{}:
-					ll.Debug("processing layout line %#v", line)
-					for _, cell := range line {
-						if val, ok := cell.(string); ok {
-							cell, err := ResolveBlockID(blocks, language, val)
-							if err != nil {
-								return layout, fmt.Errorf("while resolving block reference %q to ID: %w", val, err)
-							}
-
-							layoutLine = append(layoutLine, LayoutCell(cell))
-						} else if cell == nil {
-							ll.Debug("encountered nil value in layout line, treating as empty cell")
-							layoutLine = append(layoutLine, LayoutCell(EmptyLayoutCell))
-						}
+				row := make([]layoutCellDeclaration, 0, len(cells))
+				for _, cell := range cells {
+					declaration, err := parseLayoutCell(cell, language, blocks)
+					if err != nil {
+						return LayoutGrid{}, err
 					}
+					row = append(row, declaration)
 				}
-				layout = append(layout, layoutLine)
+				rows = append(rows, row)
 			}
 		}
 	} else {
 		// If no layout is specified, we use the default layout.
 		for _, block := range blocks {
-			layout = append(layout, []LayoutCell{LayoutCell(block.ID)})
+			rows = append(rows, []layoutCellDeclaration{{block: block.ID, columns: 1, rows: 1}})
 		}
 	}
-	ll.Debug("Layout resolved to %#v", layout)
-	return layout.Normalize(), nil
+	grid, err := newLayoutGrid(rows)
+	if err != nil {
+		return LayoutGrid{}, err
+	}
+	ll.Debug("Layout resolved to %#v", grid)
+	return grid, nil
 }
 
-// ResolveBlockID returns the ID of a block, given its ref (user-facing content block references comprising of a content block type shorthand and an index). This index is 1-based.
+// ResolveBlockID returns the ID of a block, given its ref: either the name of a block, or a content block type shorthand and an index (1-based), such as p2 for the second paragraph.
 func ResolveBlockID(blocks []ContentBlock, language string, blockRef string) (string, error) {
+	for _, block := range blocks {
+		if block.Name != "" && block.Name == blockRef {
+			return block.ID, nil
+		}
+	}
+	if !regexpMatches(PatternBlockIndexReference, blockRef) {
+		return "", fmt.Errorf("invalid content block reference: no block is named %q", blockRef)
+	}
+
 	typ, indexStr := blockRef[0:1], blockRef[1:]
 	index, err := strconv.Atoi(indexStr)
 	if err != nil {
@@ -158,3 +524,69 @@ func ResolveBlockID(blocks []ContentBlock, language string, blockRef string) (st
 	return "", fmt.Errorf("invalid content block reference: %s%d does not exist", typ, index)
 
 }
+
+// layoutReferences returns all the block references used in the layouts declared in the front matter for the given language, including breakpoint layouts.
+func layoutReferences(metadata WorkMetadata, language string) []string {
+	refs := make([]string, 0)
+	additionalMetadata := metadata.Localize(language).AdditionalMetadata
+	layouts := []interface{}{additionalMetadata["layout"]}
+	if breakpointLayouts, ok := additionalMetadata["layouts"].(map[string]interface{}); ok {
+		for _, breakpoint := range sortedKeys(breakpointLayouts) {
+			layouts = append(layouts, breakpointLayouts[breakpoint])
+		}
+	}
+	for _, layout := range layouts {
+		rows, ok := layout.([]interface{})
+		if !ok {
+			continue
+		}
+		for _, row := range rows {
+			cells, ok := row.([]interface{})
+			if !ok {
+				cells = []interface{}{row}
+			}
+			for _, cell := range cells {
+				switch cell := cell.(type) {
+				case string:
+					refs = append(refs, cell)
+				case map[string]interface{}:
+					for key, value := range cell {
+						if normalizeFrontMatterKey(key) == "block" && value != nil {
+							refs = append(refs, fmt.Sprint(value))
+						}
+					}
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// blockRef returns the ref of the block with the given ID, as it would be written in a layout. See ResolveBlockID.
+func blockRef(blocks []ContentBlock, blockID string) string {
+	currentIndexByType := map[ContentBlockType]int{"paragraph": 0, "media": 0, "link": 0}
+	for _, block := range blocks {
+		currentIndexByType[block.Type]++
+		if block.ID == blockID {
+			return fmt.Sprintf("%s%d", string(block.Type)[0:1], currentIndexByType[block.Type])
+		}
+	}
+	return blockID
+}
+
+// unusedBlockNames returns the names of blocks that are not used in any layout declared for the given language. Headings are not included, since their name is also their anchor, which can be useful on its own.
+// Returns nothing if no layout is declared.
+func unusedBlockNames(metadata WorkMetadata, language string, blocks []ContentBlock) []string {
+	refs := layoutReferences(metadata, language)
+	if len(refs) == 0 {
+		return nil
+	}
+	unused := make([]string, 0)
+	for _, block := range blocks {
+		if block.Name == "" || stringInSlice(refs, block.Name) || block.isHeading() {
+			continue
+		}
+		unused = append(unused, block.Name)
+	}
+	return unused
+}
@@ -0,0 +1,168 @@
+package ortfodb
+
+import (
+	"reflect"
+	"testing"
+)
+
+var layoutTestBlocks = []ContentBlock{
+	{ID: "P1", Type: "paragraph"},
+	{ID: "P2", Type: "paragraph"},
+	{ID: "M1", Type: "media"},
+	{ID: "M2", Type: "media", Name: "demo"},
+	{ID: "M3", Type: "media"},
+}
+
+func TestResolveLayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout interface{}
+		want   Layout
+	}{
+		{
+			name:   "default layout",
+			layout: nil,
+			want:   Layout{{"P1"}, {"P2"}, {"M1"}, {"M2"}, {"M3"}},
+		},
+		{
+			name:   "single cells on their own rows",
+			layout: []interface{}{"p2", []interface{}{"m1"}},
+			want:   Layout{{"P2"}, {"M1"}},
+		},
+		{
+			name: "rows of different widths",
+			layout: []interface{}{
+				[]interface{}{"p1", "p2"},
+				[]interface{}{"m1", "m2", "m3"},
+			},
+			want: Layout{
+				{"P1", "P1", "P1", "P2", "P2", "P2"},
+				{"M1", "M1", "M2", "M2", "M3", "M3"},
+			},
+		},
+		{
+			name: "block names and empty cells",
+			layout: []interface{}{
+				[]interface{}{nil, "demo"},
+			},
+			want: Layout{{LayoutCell(EmptyLayoutCell), "M2"}},
+		},
+		{
+			name: "spans",
+			layout: []interface{}{
+				[]interface{}{map[string]interface{}{"block": "m1", "rows": 2}, map[string]interface{}{"block": "p1", "columns": 2}},
+				[]interface{}{"p2", "m2"},
+			},
+			want: Layout{
+				{"M1", "P1", "P1"},
+				{"M1", "P2", "M2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := WorkMetadata{AdditionalMetadata: map[string]interface{}{"layout": tt.layout}}
+			got, err := ResolveLayout(metadata, "en", layoutTestBlocks)
+			if err != nil {
+				t.Fatalf("ResolveLayout() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveLayout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLayoutGrid(t *testing.T) {
+	cell := func(block string, columns, rows int) layoutCellDeclaration {
+		return layoutCellDeclaration{block: block, columns: columns, rows: rows}
+	}
+	tests := []struct {
+		name    string
+		rows    [][]layoutCellDeclaration
+		want    LayoutGrid
+		wantErr bool
+	}{
+		{
+			name: "no rows",
+			rows: nil,
+			want: LayoutGrid{Items: []LayoutItem{}},
+		},
+		{
+			name: "rows of different widths",
+			rows: [][]layoutCellDeclaration{
+				{cell("P1", 1, 1), cell("P2", 1, 1)},
+				{cell("M1", 1, 1), cell("M2", 1, 1), cell("M3", 1, 1)},
+			},
+			want: LayoutGrid{Columns: 6, Rows: 2, Items: []LayoutItem{
+				{Block: "P1", Row: 1, Column: 1, RowSpan: 1, ColumnSpan: 3},
+				{Block: "P2", Row: 1, Column: 4, RowSpan: 1, ColumnSpan: 3},
+				{Block: "M1", Row: 2, Column: 1, RowSpan: 1, ColumnSpan: 2},
+				{Block: "M2", Row: 2, Column: 3, RowSpan: 1, ColumnSpan: 2},
+				{Block: "M3", Row: 2, Column: 5, RowSpan: 1, ColumnSpan: 2},
+			}},
+		},
+		{
+			name: "repeated cells are merged",
+			rows: [][]layoutCellDeclaration{
+				{cell("P1", 1, 1), cell("P1", 1, 1), cell("M1", 1, 1)},
+				{cell("P1", 1, 1), cell("P1", 1, 1), cell("M2", 1, 1)},
+			},
+			want: LayoutGrid{Columns: 3, Rows: 2, Items: []LayoutItem{
+				{Block: "P1", Row: 1, Column: 1, RowSpan: 2, ColumnSpan: 2},
+				{Block: "M1", Row: 1, Column: 3, RowSpan: 1, ColumnSpan: 1},
+				{Block: "M2", Row: 2, Column: 3, RowSpan: 1, ColumnSpan: 1},
+			}},
+		},
+		{
+			name: "cell spanning rows",
+			rows: [][]layoutCellDeclaration{
+				{cell("M1", 1, 2), cell("P1", 1, 1)},
+				{cell("P2", 1, 1)},
+			},
+			want: LayoutGrid{Columns: 2, Rows: 2, Items: []LayoutItem{
+				{Block: "M1", Row: 1, Column: 1, RowSpan: 2, ColumnSpan: 1},
+				{Block: "P1", Row: 1, Column: 2, RowSpan: 1, ColumnSpan: 1},
+				{Block: "P2", Row: 2, Column: 2, RowSpan: 1, ColumnSpan: 1},
+			}},
+		},
+		{
+			name: "empty cells are not items",
+			rows: [][]layoutCellDeclaration{
+				{cell("", 1, 1), cell("P1", 1, 1)},
+			},
+			want: LayoutGrid{Columns: 2, Rows: 1, Items: []LayoutItem{
+				{Block: "P1", Row: 1, Column: 2, RowSpan: 1, ColumnSpan: 1},
+			}},
+		},
+		{
+			name: "cell spanning past the last row",
+			rows: [][]layoutCellDeclaration{
+				{cell("M1", 1, 2)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "cells not lining up",
+			rows: [][]layoutCellDeclaration{
+				{cell("M1", 1, 2), cell("P1", 1, 1)},
+				{cell("P2", 1, 1), cell("M2", 1, 1), cell("M3", 1, 1)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newLayoutGrid(tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newLayoutGrid() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newLayoutGrid() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
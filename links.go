@@ -0,0 +1,185 @@
+package ortfodb
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	ll "github.com/gwennlbh/label-logger-go"
+)
+
+// PatternWorkLink matches link targets that might refer to another work: a work ID or alias, optionally followed by a block anchor, for example "other-work" or "other-work#some-block".
+const PatternWorkLink = `^([\w-]+)(?:#(\S+))?$`
+
+var hrefAttributePattern = regexp.MustCompile(`(\shref=")([^"]*)"`)
+
+// WorkReference is a link, in a content block, to another work.
+type WorkReference struct {
+	// ID or alias of the linked work, as written in the description file.
+	Target string `json:"target"`
+	// Anchor of the linked block, if any.
+	Anchor string `json:"anchor"`
+	// ID of the linked work. Empty if it does not exist.
+	Work string `json:"work"`
+	// URL the link was rewritten to, see LinksConfiguration.
+	URL string `json:"url"`
+}
+
+// WorkURL returns the URL that links to the given work (and block anchor, if not empty) are rewritten to, for the given language.
+func (ctx *RunContext) WorkURL(language string, workID string, anchor string) string {
+	template := ctx.Config.Links.WorkURL
+	if localized, ok := ctx.Config.Links.LocalizedWorkURL[language]; ok {
+		template = localized
+	}
+	if template == "" {
+		template = "<work id>"
+	}
+	url := strings.NewReplacer("<work id>", workID, "<language>", language).Replace(template)
+	if anchor != "" {
+		url += "#" + anchor
+	}
+	return url
+}
+
+// ResolveWorkLinks finds links to other works in every block of the given works, rewrites them with WorkURL and records them in the blocks' References.
+// Links are recognized with PatternWorkLink, and must not point to a file in the work's folder. Unless links.dangling is set to "error" in the configuration, they must also name an existing work or alias, so that links like [contact me](contact) are left alone.
+// Links that were already resolved by a previous build are resolved again, so that links to works that were renamed or removed since are caught.
+// Links to works or anchors that do not exist are warned about, or make this return an error if links.dangling is set to "error".
+func (ctx *RunContext) ResolveWorkLinks(works Database) error {
+	for _, workID := range sortedKeys(works) {
+		work := works[workID]
+		for _, language := range sortedKeys(work.Content) {
+			content := work.Content[language]
+			for i, block := range content.Blocks {
+				resolved, references, err := ctx.resolveBlockWorkLinks(works, workID, language, block)
+				if err != nil {
+					return err
+				}
+				resolved.References = references
+				content.Blocks[i] = resolved
+			}
+			work.Content[language] = content
+		}
+		works[workID] = work
+	}
+	return nil
+}
+
+func (ctx *RunContext) resolveBlockWorkLinks(works Database, workID string, language string, block ContentBlock) (ContentBlock, []WorkReference, error) {
+	previous := make(map[string]WorkReference, len(block.References))
+	for _, reference := range block.References {
+		previous[reference.URL] = reference
+	}
+
+	references := make([]WorkReference, 0)
+	var err error
+	resolve := func(href string) string {
+		if err != nil {
+			return href
+		}
+		reference, ok := previous[href]
+		if !ok {
+			reference, ok = ctx.parseWorkLink(workID, href)
+			if _, found := works.FindWork(reference.Target); ok && !found && ctx.Config.Links.Dangling != SeverityError {
+				ok = false
+			}
+		}
+		if !ok {
+			return href
+		}
+
+		reference.Work = ""
+		reference.URL = href
+		var problem string
+		if target, found := works.FindWork(reference.Target); !found {
+			problem = fmt.Sprintf("work %s links to %s, which does not exist", workID, reference.Target)
+		} else if reference.Anchor != "" && !hasAnchor(target.Content.Localize(language), reference.Anchor) {
+			problem = fmt.Sprintf("work %s links to %s#%s, but %s has no such block", workID, reference.Target, reference.Anchor, target.ID)
+		} else {
+			reference.Work = target.ID
+			reference.URL = ctx.WorkURL(language, target.ID, reference.Anchor)
+		}
+		references = append(references, reference)
+
+		if problem != "" {
+			if ctx.Config.Links.Dangling == SeverityError {
+				err = errors.New(problem)
+			} else {
+				ll.Warn("%s", problem)
+			}
+		}
+		return reference.URL
+	}
+
+	switch {
+	case block.Type.IsParagraph():
+		block.Content = HTMLString(hrefAttributePattern.ReplaceAllStringFunc(string(block.Content), func(attribute string) string {
+			groups := hrefAttributePattern.FindStringSubmatch(attribute)
+			href := html.UnescapeString(groups[2])
+			if resolved := resolve(href); resolved != href {
+				return groups[1] + html.EscapeString(resolved) + `"`
+			}
+			return attribute
+		}))
+	case block.Type == "link":
+		block.URL = resolve(block.URL)
+	}
+
+	return block, references, err
+}
+
+// parseWorkLink returns the reference that href might be, if it matches PatternWorkLink and does not point to a file in the work's folder.
+func (ctx *RunContext) parseWorkLink(workID string, href string) (WorkReference, bool) {
+	groups := regexp.MustCompile(PatternWorkLink).FindStringSubmatch(href)
+	if groups == nil {
+		return WorkReference{}, false
+	}
+	if _, err := os.Stat(filepath.Join(ctx.PathToWorkFolder(workID), groups[1])); err == nil {
+		return WorkReference{}, false
+	}
+	return WorkReference{Target: groups[1], Anchor: groups[2]}, true
+}
+
+func hasAnchor(content LocalizedContent, anchor string) bool {
+	for _, block := range content.Blocks {
+		if block.Anchor == anchor || block.ID == anchor {
+			return true
+		}
+	}
+	return false
+}
+
+// unresolveWorkLinks reverts what ResolveWorkLinks did to the given block: links to other works are written back as they were in the description file, using the block's References.
+func unresolveWorkLinks(block ContentBlock) ContentBlock {
+	written := make(map[string]string, len(block.References))
+	for _, reference := range block.References {
+		written[reference.URL] = reference.Target
+		if reference.Anchor != "" {
+			written[reference.URL] += "#" + reference.Anchor
+		}
+	}
+	if len(written) == 0 {
+		return block
+	}
+
+	switch {
+	case block.Type.IsParagraph():
+		block.Content = HTMLString(hrefAttributePattern.ReplaceAllStringFunc(string(block.Content), func(attribute string) string {
+			groups := hrefAttributePattern.FindStringSubmatch(attribute)
+			if href, ok := written[html.UnescapeString(groups[2])]; ok {
+				return groups[1] + html.EscapeString(href) + `"`
+			}
+			return attribute
+		}))
+	case block.Type == "link":
+		if href, ok := written[block.URL]; ok {
+			block.URL = href
+		}
+	}
+	block.References = nil
+	return block
+}
@@ -0,0 +1,608 @@
+package ortfodb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/metal3d/go-slugify"
+)
+
+// DiagnosticSeverity is one of "error", "warning" or "info".
+type DiagnosticSeverity string
+
+const (
+	SeverityError   DiagnosticSeverity = "error"
+	SeverityWarning DiagnosticSeverity = "warning"
+	SeverityInfo    DiagnosticSeverity = "info"
+)
+
+// Diagnostic is a problem found in a description file by Lint.
+type Diagnostic struct {
+	// Path to the description file.
+	File string `json:"file"`
+	// ID of the work described by the file.
+	Work string `json:"work"`
+	// Line and column (both starting at 1) where the problem is. Column is counted in characters, not bytes.
+	Line     int                `json:"line"`
+	Column   int                `json:"column"`
+	Severity DiagnosticSeverity `json:"severity"`
+	// Name of the rule that produced the diagnostic, see LintRules.
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Diagnostics is a list of diagnostics, sorted by file and position.
+type Diagnostics []Diagnostic
+
+// HasErrors returns true if any of the diagnostics has an error severity.
+func (diagnostics Diagnostics) HasErrors() bool {
+	return some(diagnostics, func(d Diagnostic) bool { return d.Severity == SeverityError })
+}
+
+func (diagnostics Diagnostics) sort() {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
+
+// LintRule describes a check done by Lint.
+type LintRule struct {
+	Name        string
+	Description string
+	Severity    DiagnosticSeverity
+}
+
+// LintRules lists all the checks done by Lint.
+var LintRules = []LintRule{
+	{"front-matter", "The front matter has a syntax error or a value of the wrong type", SeverityError},
+	{"front-matter-key", "A front matter key looks like a misspelled known key", SeverityWarning},
+	{"syntax", "The description cannot be parsed, for example because two blocks have the same name", SeverityError},
+	{"invalid-date", "A date (started, finished or created) cannot be parsed", SeverityError},
+	{"unknown-tag", "A tag is not declared in the tags repository", SeverityWarning},
+	{"unknown-technology", "A technology is not declared in the technologies repository", SeverityWarning},
+	{"unknown-collection", "A collection is not declared in the collections repository", SeverityError},
+	{"missing-alt", "A media has no alt text", SeverityWarning},
+	{"missing-media", "A media file does not exist", SeverityError},
+	{"layout-reference", "A block reference in the layout does not resolve to a block", SeverityError},
+	{"unused-block-name", "A block is named, but the name is not used in any layout", SeverityError},
+	{"missing-translation", "A language has fewer blocks than another one", SeverityWarning},
+	{"broken-link", "A link points to an anchor, a file or a work that does not exist", SeverityError},
+}
+
+func lintRule(name string) LintRule {
+	for _, rule := range LintRules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	panic(fmt.Sprintf("unknown lint rule %q", name))
+}
+
+// descriptionSource holds the lines of a description file, to find out where things are declared.
+type descriptionSource struct {
+	filename string
+	workID   string
+	lines    []string
+}
+
+// find returns the position of the first occurrence of text at or after line fromLine.
+// Lines before fromLine are searched if nothing is found after it.
+// If text cannot be found at all, the start of fromLine is returned.
+func (s descriptionSource) find(text string, fromLine int) (line int, column int) {
+	if fromLine < 1 {
+		fromLine = 1
+	}
+	if text != "" {
+		for _, start := range []int{fromLine, 1} {
+			for i := start - 1; i < len(s.lines); i++ {
+				if index := strings.Index(s.lines[i], text); index != -1 {
+					return i + 1, utf8.RuneCountInString(s.lines[i][:index]) + 1
+				}
+			}
+		}
+	}
+	return fromLine, 1
+}
+
+// findMedia returns the position of the embed declaration of the media with the given source, at or after line fromLine.
+func (s descriptionSource) findMedia(src string, fromLine int) (line int, column int) {
+	for _, start := range []int{max(fromLine, 1), 1} {
+		for i := start - 1; i < len(s.lines); i++ {
+			trimmed := strings.TrimSpace(s.lines[i])
+			if (strings.HasPrefix(trimmed, "![") || strings.HasPrefix(trimmed, ">[")) && strings.Contains(trimmed, src) {
+				return i + 1, utf8.RuneCountInString(s.lines[i][:strings.Index(s.lines[i], trimmed)]) + 1
+			}
+		}
+	}
+	return s.find(src, fromLine)
+}
+
+// languageLine returns the line of the language marker for the given language, or 1 if there is none.
+func (s descriptionSource) languageLine(language string) int {
+	pattern := regexp.MustCompile(PatternLanguageMarker)
+	for i, line := range s.lines {
+		if groups := pattern.FindStringSubmatch(line); groups != nil && strings.TrimSpace(groups[1]) == language {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+func (s descriptionSource) diagnostic(rule string, line int, column int, message string, args ...any) Diagnostic {
+	return Diagnostic{
+		File:     s.filename,
+		Work:     s.workID,
+		Line:     line,
+		Column:   column,
+		Severity: lintRule(rule).Severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(message, args...),
+	}
+}
+
+// Lint checks the description files of all works whose ID matches one of the given patterns (see filepath.Match). All works are checked if no pattern is given.
+// Nothing is built: media files are not analyzed nor copied, and the database file is not written.
+func (ctx *RunContext) Lint(patterns ...string) (Diagnostics, error) {
+	workDirectories, err := ctx.ComputeProgressTotal()
+	if err != nil {
+		return nil, fmt.Errorf("while listing works: %w", err)
+	}
+
+	if _, err := ctx.LoadTagsRepository(); err != nil {
+		return nil, fmt.Errorf("while loading tags repository: %w", err)
+	}
+	if _, err := ctx.LoadTechnologiesRepository(); err != nil {
+		return nil, fmt.Errorf("while loading technologies repository: %w", err)
+	}
+	if _, err := ctx.LoadCollectionsRepository(); err != nil {
+		return nil, fmt.Errorf("while loading collections repository: %w", err)
+	}
+
+	diagnostics := make(Diagnostics, 0)
+	for _, dirEntry := range workDirectories {
+		workID := dirEntry.Name()
+		included := len(patterns) == 0
+		for _, pattern := range patterns {
+			matched, err := filepath.Match(pattern, workID)
+			if err != nil {
+				return nil, fmt.Errorf("while testing pattern %q: %w", pattern, err)
+			}
+			included = included || matched
+		}
+		if !included {
+			continue
+		}
+
+		workDiagnostics, err := ctx.LintWork(workID)
+		if err != nil {
+			return nil, fmt.Errorf("while linting %s: %w", workID, err)
+		}
+		diagnostics = append(diagnostics, workDiagnostics...)
+	}
+
+	diagnostics.sort()
+	return diagnostics, nil
+}
+
+// LintWork checks the description file of a single work. See Lint.
+func (ctx *RunContext) LintWork(workID string) (Diagnostics, error) {
+	filename := ctx.DescriptionFilename(ctx.DatabaseDirectory, workID)
+	raw, err := readFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("while reading description file %s: %w", filename, err)
+	}
+	source := descriptionSource{filename: filename, workID: workID, lines: strings.Split(raw, "\n")}
+	diagnostics := make(Diagnostics, 0)
+
+	metadata, frontMatter, markdownRaw, err := ParseFrontMatter[WorkMetadata](raw)
+	for _, warning := range frontMatter.Warnings {
+		line, column := source.find(warning.Key, warning.Line)
+		diagnostics = append(diagnostics, source.diagnostic("front-matter-key", line, column, "%s", warning.Message))
+	}
+	if err != nil {
+		var issues FrontMatterIssues
+		if !errors.As(err, &issues) {
+			return nil, err
+		}
+		for _, issue := range issues {
+			rule := "front-matter"
+			if stringInSlice([]string{"started", "finished"}, normalizeFrontMatterKey(issue.Key)) {
+				rule = "invalid-date"
+			}
+			diagnostics = append(diagnostics, source.diagnostic(rule, max(issue.Line, 1), 1, "%s", issue.Message))
+		}
+		// The rest of the checks need the metadata
+		return diagnostics, nil
+	}
+
+	diagnostics = append(diagnostics, ctx.lintMetadata(source, metadata, frontMatter)...)
+
+	notLocalizedRaw, localizedRawBlocks := SplitOnLanguageMarkers(markdownRaw)
+	languages := []string{"default"}
+	if len(localizedRawBlocks) > 0 {
+		languages = mapKeys(localizedRawBlocks)
+		sort.Strings(languages)
+	}
+
+	contents := make(map[string]LocalizedContent, len(languages))
+	for _, language := range languages {
+		var content LocalizedContent
+		content.Title, content.Blocks, content.Footnotes, content.Abbreviations, err = ctx.ParseSingleLanguageDescription(notLocalizedRaw + localizedRawBlocks[language])
+		if err != nil {
+			diagnostics = append(diagnostics, source.diagnostic("syntax", source.languageLine(language), 1, "%s", err.Error()))
+			continue
+		}
+		contents[language] = content
+
+		diagnostics = append(diagnostics, ctx.lintBlocks(source, language, content)...)
+	}
+
+	diagnostics = append(diagnostics, lintLayout(source, metadata, frontMatter, contents)...)
+
+	diagnostics = append(diagnostics, lintTranslations(source, contents)...)
+	diagnostics.sort()
+	return diagnostics, nil
+}
+
+func (ctx *RunContext) lintMetadata(source descriptionSource, metadata WorkMetadata, frontMatter FrontMatter) Diagnostics {
+	diagnostics := make(Diagnostics, 0)
+
+	// started and finished are already checked while decoding the front matter
+	if _, err := metadata.CreationDate(); err != nil {
+		line, column := source.find(fmt.Sprint(metadata.AdditionalMetadata["created"]), frontMatter.KeyLine("created"))
+		diagnostics = append(diagnostics, source.diagnostic("invalid-date", line, column, "%s", errors.Unwrap(err).Error()))
+	}
+
+	if len(ctx.TagsRepository) > 0 {
+		for _, tag := range metadata.Tags {
+			if _, ok := ctx.FindTag(tag); !ok {
+				line, column := source.find(tag, frontMatter.KeyLine("tags"))
+				diagnostics = append(diagnostics, source.diagnostic("unknown-tag", line, column, "tag %q is not declared in %s", tag, ctx.Config.Tags.Repository))
+			}
+		}
+		for _, language := range sortedKeys(metadata.Localized) {
+			for _, tag := range metadata.Localized[language].Tags {
+				if _, ok := ctx.FindTag(tag); !ok {
+					line, column := source.find(tag, frontMatter.KeyLine("localized"))
+					diagnostics = append(diagnostics, source.diagnostic("unknown-tag", line, column, "tag %q (in %s) is not declared in %s", tag, language, ctx.Config.Tags.Repository))
+				}
+			}
+		}
+	}
+
+	if len(ctx.TechnologiesRepository) > 0 {
+		for _, technology := range metadata.MadeWith {
+			if _, ok := ctx.FindTechnology(technology); !ok {
+				line, column := source.find(technology, frontMatter.KeyLine("made with"))
+				diagnostics = append(diagnostics, source.diagnostic("unknown-technology", line, column, "technology %q is not declared in %s", technology, ctx.Config.Technologies.Repository))
+			}
+		}
+	}
+
+	if ctx.Config.Collections.Repository != "" {
+		for _, collection := range metadata.Collections {
+			if !some(ctx.CollectionsRepository, func(c Collection) bool { return c.ID == collection }) {
+				line, column := source.find(collection, frontMatter.KeyLine("collections"))
+				diagnostics = append(diagnostics, source.diagnostic("unknown-collection", line, column, "collection %q is not declared in %s", collection, ctx.Config.Collections.Repository))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+func (ctx *RunContext) lintBlocks(source descriptionSource, language string, content LocalizedContent) Diagnostics {
+	diagnostics := make(Diagnostics, 0)
+	fromLine := source.languageLine(language)
+
+	anchors := make([]string, 0)
+	for name := range content.Footnotes {
+		anchors = append(anchors, "fn:"+name)
+	}
+	idPattern := regexp.MustCompile(`\sid="([^"]+)"`)
+	for _, block := range content.Blocks {
+		anchors = append(anchors, block.Anchor)
+		if block.Type.IsMedia() {
+			anchors = append(anchors, slugify.Marshal(filepathBaseNoExt(string(block.RelativeSource)), true))
+		}
+		for _, groups := range idPattern.FindAllStringSubmatch(string(block.Content), -1) {
+			anchors = append(anchors, groups[1])
+		}
+	}
+
+	hrefPattern := regexp.MustCompile(`\shref="([^"]*)"`)
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "media":
+			src := string(block.RelativeSource)
+			line, column := source.findMedia(src, fromLine)
+			if strings.TrimSpace(block.Alt) == "" {
+				diagnostics = append(diagnostics, source.diagnostic("missing-alt", line, column, "media %s has no alt text", src))
+			}
+			if !isValidURL(src) {
+				if _, err := os.Stat(filepath.Join(ctx.PathToWorkFolder(source.workID), src)); os.IsNotExist(err) {
+					diagnostics = append(diagnostics, source.diagnostic("missing-media", line, column, "media file %s does not exist", src))
+				}
+			}
+		case "link":
+			diagnostics = append(diagnostics, ctx.lintLink(source, fromLine, block.URL, anchors)...)
+		case "paragraph":
+			for _, groups := range hrefPattern.FindAllStringSubmatch(string(block.Content), -1) {
+				diagnostics = append(diagnostics, ctx.lintLink(source, fromLine, groups[1], anchors)...)
+			}
+		}
+	}
+	for _, footnote := range content.Footnotes {
+		for _, groups := range hrefPattern.FindAllStringSubmatch(string(footnote), -1) {
+			diagnostics = append(diagnostics, ctx.lintLink(source, fromLine, groups[1], anchors)...)
+		}
+	}
+
+	return diagnostics
+}
+
+// lintLink checks that links to anchors point to an existing anchor, and that relative links point to existing files.
+func (ctx *RunContext) lintLink(source descriptionSource, fromLine int, href string, anchors []string) Diagnostics {
+	href = strings.ReplaceAll(href, "&amp;", "&")
+	if href == "" || strings.HasPrefix(href, "/") || strings.HasPrefix(href, "#fnref:") {
+		return nil
+	}
+
+	if anchor, ok := strings.CutPrefix(href, "#"); ok {
+		if !stringInSlice(anchors, anchor) {
+			line, column := source.find(href, fromLine)
+			return Diagnostics{source.diagnostic("broken-link", line, column, "link to #%s, but there is no such anchor", anchor)}
+		}
+		return nil
+	}
+
+	parsed, err := url.Parse(href)
+	if err != nil || parsed.Scheme != "" || parsed.Host != "" || parsed.Path == "" {
+		return nil
+	}
+
+	target, err := url.PathUnescape(parsed.Path)
+	if err != nil {
+		target = parsed.Path
+	}
+	if _, err := os.Stat(filepath.Join(ctx.PathToWorkFolder(source.workID), target)); os.IsNotExist(err) {
+		if reference, ok := ctx.parseWorkLink(source.workID, href); ok {
+			if stringInSlice(ctx.workIDsAndAliases(), reference.Target) {
+				return nil
+			}
+			line, column := source.find(href, fromLine)
+			return Diagnostics{source.diagnostic("broken-link", line, column, "link to %s, but there is no such file or work", target)}
+		}
+		line, column := source.find(href, fromLine)
+		return Diagnostics{source.diagnostic("broken-link", line, column, "link to %s, but there is no such file", target)}
+	}
+	return nil
+}
+
+// workIDsAndAliases returns the IDs and aliases of all works, to check links to other works without building them.
+// Works whose description file cannot be read or parsed only contribute their ID.
+func (ctx *RunContext) workIDsAndAliases() []string {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.knownWorks != nil {
+		return ctx.knownWorks
+	}
+
+	ctx.knownWorks = make([]string, 0)
+	workDirectories, err := ctx.ComputeProgressTotal()
+	if err != nil {
+		return ctx.knownWorks
+	}
+	for _, dirEntry := range workDirectories {
+		ctx.knownWorks = append(ctx.knownWorks, dirEntry.Name())
+		raw, err := readFile(ctx.DescriptionFilename(ctx.DatabaseDirectory, dirEntry.Name()))
+		if err != nil {
+			continue
+		}
+		metadata, _, _, err := ParseFrontMatter[WorkMetadata](raw)
+		if err != nil {
+			continue
+		}
+		ctx.knownWorks = append(ctx.knownWorks, metadata.Aliases...)
+	}
+	return ctx.knownWorks
+}
+
+func lintLayout(source descriptionSource, metadata WorkMetadata, frontMatter FrontMatter, contents map[string]LocalizedContent) Diagnostics {
+	diagnostics := make(Diagnostics, 0)
+	languages := mapKeys(contents)
+	sort.Strings(languages)
+	allRefs := make([]string, 0)
+	for _, language := range languages {
+		allRefs = append(allRefs, layoutReferences(metadata, language)...)
+	}
+	for _, ref := range noDuplicates(allRefs) {
+		var message string
+		failingLanguages := make([]string, 0)
+		for _, language := range languages {
+			if !stringInSlice(layoutReferences(metadata, language), ref) {
+				continue
+			}
+			if _, err := ResolveBlockID(contents[language].Blocks, language, ref); err != nil {
+				message = err.Error()
+				failingLanguages = append(failingLanguages, language)
+			}
+		}
+		if len(failingLanguages) == 0 {
+			continue
+		}
+		if len(failingLanguages) < len(languages) {
+			message = fmt.Sprintf("in %s: %s", strings.Join(failingLanguages, ", "), message)
+		}
+		line, column := source.find(ref, frontMatter.KeyLine("layout"))
+		diagnostics = append(diagnostics, source.diagnostic("layout-reference", line, column, "%s", message))
+	}
+
+	unusedNames := make([]string, 0)
+	for _, language := range languages {
+		unusedNames = append(unusedNames, unusedBlockNames(metadata, language, contents[language].Blocks)...)
+	}
+	for _, name := range noDuplicates(unusedNames) {
+		line, column := source.find("{#"+name+"}", 1)
+		diagnostics = append(diagnostics, source.diagnostic("unused-block-name", line, column, "block named %q is not used in the layout", name))
+	}
+	return diagnostics
+}
+
+// lintTranslations checks that every language has the same media and the same number of paragraphs and links.
+func lintTranslations(source descriptionSource, contents map[string]LocalizedContent) Diagnostics {
+	diagnostics := make(Diagnostics, 0)
+	if len(contents) < 2 {
+		return diagnostics
+	}
+
+	languages := mapKeys(contents)
+	sort.Strings(languages)
+
+	mediaSources := make(map[string][]string)
+	for _, language := range languages {
+		for _, block := range contents[language].Blocks {
+			if block.Type.IsMedia() {
+				mediaSources[language] = append(mediaSources[language], string(block.RelativeSource))
+			}
+		}
+	}
+
+	for _, language := range languages {
+		for _, other := range languages {
+			for _, src := range mediaSources[other] {
+				if !stringInSlice(mediaSources[language], src) {
+					line, column := source.findMedia(src, source.languageLine(other))
+					diagnostics = append(diagnostics, source.diagnostic("missing-translation", line, column, "media %s is missing in %s", src, language))
+				}
+			}
+		}
+
+		for _, typ := range []ContentBlockType{"paragraph", "link"} {
+			count := func(language string) int {
+				n := 0
+				for _, block := range contents[language].Blocks {
+					if block.Type == typ {
+						n++
+					}
+				}
+				return n
+			}
+			mostLanguage := language
+			for _, other := range languages {
+				if count(other) > count(mostLanguage) {
+					mostLanguage = other
+				}
+			}
+			if count(language) < count(mostLanguage) {
+				diagnostics = append(diagnostics, source.diagnostic("missing-translation", source.languageLine(language), 1, "%s has %d %ss, but %s has %d", language, count(language), typ, mostLanguage, count(mostLanguage)))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// JSON returns the diagnostics as a JSON array.
+func (diagnostics Diagnostics) JSON() ([]byte, error) {
+	return json.MarshalIndent(diagnostics, "", "  ")
+}
+
+// SARIF returns the diagnostics as a SARIF 2.1.0 log, to be consumed by CI tools such as GitHub code scanning.
+func (diagnostics Diagnostics) SARIF() ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID                   string  `json:"id"`
+		ShortDescription     message `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+	}
+	type location struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region region `json:"region"`
+		} `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	sarifLevel := func(severity DiagnosticSeverity) string {
+		switch severity {
+		case SeverityError:
+			return "error"
+		case SeverityWarning:
+			return "warning"
+		}
+		return "note"
+	}
+
+	rules := make([]rule, 0, len(LintRules))
+	for _, lintRule := range LintRules {
+		r := rule{ID: lintRule.Name, ShortDescription: message{lintRule.Description}}
+		r.DefaultConfiguration.Level = sarifLevel(lintRule.Severity)
+		rules = append(rules, r)
+	}
+
+	results := make([]result, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(diagnostic.File)
+		loc.PhysicalLocation.Region = region{StartLine: diagnostic.Line, StartColumn: diagnostic.Column}
+		results = append(results, result{
+			RuleID:    diagnostic.Rule,
+			Level:     sarifLevel(diagnostic.Severity),
+			Message:   message{diagnostic.Message},
+			Locations: []location{loc},
+		})
+	}
+
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{
+				"driver": map[string]any{
+					"name":           "ortfodb",
+					"version":        Version,
+					"informationUri": "https://ortfo.org/db",
+					"rules":          rules,
+				},
+			},
+			"results": results,
+		}},
+	}
+
+	return json.MarshalIndent(log, "", "  ")
+}
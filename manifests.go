@@ -0,0 +1,293 @@
+package ortfodb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Manifest holds information about a work found in a package manager's manifest file, such as package.json or Cargo.toml.
+type Manifest struct {
+	// Path of the manifest file, relative to the work's folder.
+	Path string
+	// Package ecosystem the manifest belongs to: npm, cargo, go, pypi or rubygems. See ManifestFiles.
+	Ecosystem   string
+	Name        string
+	Description string
+	Homepage    string
+	License     string
+	// Names of the packages the work depends on, including development dependencies, sorted.
+	Dependencies []string
+}
+
+// ManifestFiles maps names of the manifest files that are parsed to the package ecosystem they belong to.
+var ManifestFiles = map[string]string{
+	"package.json":   "npm",
+	"Cargo.toml":     "cargo",
+	"go.mod":         "go",
+	"pyproject.toml": "pypi",
+	"Gemfile":        "rubygems",
+}
+
+// IsManifest returns true if filename (a path or a file name) is one of the manifest files that can be parsed.
+func IsManifest(filename string) bool {
+	_, ok := ManifestFiles[path.Base(filename)]
+	return ok
+}
+
+// ParseManifest parses the contents of a manifest file. filename is only used to know which kind of manifest it is, see ManifestFiles.
+func ParseManifest(filename string, contents []byte) (Manifest, error) {
+	manifest := Manifest{Path: filename, Ecosystem: ManifestFiles[path.Base(filename)]}
+	dependencies := make(map[string]bool)
+	var err error
+
+	switch manifest.Ecosystem {
+	case "npm":
+		err = parsePackageJSON(contents, &manifest, dependencies)
+	case "cargo":
+		err = parseCargoToml(contents, &manifest, dependencies)
+	case "go":
+		parseGoMod(contents, &manifest, dependencies)
+	case "pypi":
+		err = parsePyprojectToml(contents, &manifest, dependencies)
+	case "rubygems":
+		parseGemfile(contents, dependencies)
+	default:
+		return Manifest{}, fmt.Errorf("%s is not a manifest file that can be parsed", filename)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("while parsing %s: %w", filename, err)
+	}
+
+	manifest.Dependencies = make([]string, 0, len(dependencies))
+	for dependency := range dependencies {
+		manifest.Dependencies = append(manifest.Dependencies, dependency)
+	}
+	sort.Strings(manifest.Dependencies)
+	return manifest, nil
+}
+
+// DependsOn returns true if the manifest lists the given package as a dependency.
+// The package name can be prefixed with an ecosystem to only match manifests of that ecosystem, for example "npm:svelte" or "go:github.com/spf13/cobra".
+// Names are compared case-insensitively, and Python package names are normalized.
+func (m Manifest) DependsOn(dependency string) bool {
+	if ecosystem, name, found := strings.Cut(dependency, ":"); found && isEcosystem(ecosystem) {
+		if ecosystem != m.Ecosystem {
+			return false
+		}
+		dependency = name
+	}
+	for _, candidate := range m.Dependencies {
+		if m.Ecosystem == "pypi" && normalizePythonPackageName(candidate) == normalizePythonPackageName(dependency) {
+			return true
+		}
+		if strings.EqualFold(candidate, dependency) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEcosystem(name string) bool {
+	for _, ecosystem := range ManifestFiles {
+		if ecosystem == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePackageJSON(contents []byte, manifest *Manifest, dependencies map[string]bool) error {
+	var packageJSON struct {
+		Name                 string            `json:"name"`
+		Description          string            `json:"description"`
+		Homepage             string            `json:"homepage"`
+		License              any               `json:"license"`
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(contents, &packageJSON); err != nil {
+		return err
+	}
+
+	manifest.Name = packageJSON.Name
+	manifest.Description = packageJSON.Description
+	manifest.Homepage = packageJSON.Homepage
+	// Older packages use {"type": "MIT", "url": "..."}
+	switch license := packageJSON.License.(type) {
+	case string:
+		manifest.License = license
+	case map[string]any:
+		manifest.License, _ = license["type"].(string)
+	}
+
+	for _, group := range []map[string]string{packageJSON.Dependencies, packageJSON.DevDependencies, packageJSON.PeerDependencies, packageJSON.OptionalDependencies} {
+		for name := range group {
+			dependencies[name] = true
+		}
+	}
+	return nil
+}
+
+func parseCargoToml(contents []byte, manifest *Manifest, dependencies map[string]bool) error {
+	var cargoToml struct {
+		Package struct {
+			Name        string `toml:"name"`
+			Description string `toml:"description"`
+			Homepage    string `toml:"homepage"`
+			Repository  string `toml:"repository"`
+			License     string `toml:"license"`
+		} `toml:"package"`
+		Dependencies      map[string]any `toml:"dependencies"`
+		DevDependencies   map[string]any `toml:"dev-dependencies"`
+		BuildDependencies map[string]any `toml:"build-dependencies"`
+		Workspace         struct {
+			Dependencies map[string]any `toml:"dependencies"`
+		} `toml:"workspace"`
+	}
+	if _, err := toml.Decode(string(contents), &cargoToml); err != nil {
+		return err
+	}
+
+	manifest.Name = cargoToml.Package.Name
+	manifest.Description = cargoToml.Package.Description
+	manifest.Homepage = cargoToml.Package.Homepage
+	if manifest.Homepage == "" {
+		manifest.Homepage = cargoToml.Package.Repository
+	}
+	manifest.License = cargoToml.Package.License
+
+	for _, group := range []map[string]any{cargoToml.Dependencies, cargoToml.DevDependencies, cargoToml.BuildDependencies, cargoToml.Workspace.Dependencies} {
+		for name, declaration := range group {
+			// Renamed dependencies, such as foo = { package = "bar" }, depend on bar
+			if table, ok := declaration.(map[string]any); ok {
+				if actualName, ok := table["package"].(string); ok {
+					name = actualName
+				}
+			}
+			dependencies[name] = true
+		}
+	}
+	return nil
+}
+
+func parseGoMod(contents []byte, manifest *Manifest, dependencies map[string]bool) {
+	inRequireBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequireBlock && fields[0] == ")":
+			inRequireBlock = false
+		case inRequireBlock:
+			dependencies[fields[0]] = true
+		case fields[0] == "module" && len(fields) >= 2:
+			manifest.Name = strings.Trim(fields[1], `"`)
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inRequireBlock = true
+		case fields[0] == "require" && len(fields) >= 2:
+			dependencies[fields[1]] = true
+		}
+	}
+}
+
+// pythonRequirementName matches the package name at the start of a PEP 508 requirement, such as "django>=4.2; python_version>'3.8'".
+var pythonRequirementName = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+func parsePyprojectToml(contents []byte, manifest *Manifest, dependencies map[string]bool) error {
+	var pyprojectToml struct {
+		Project struct {
+			Name                 string              `toml:"name"`
+			Description          string              `toml:"description"`
+			License              any                 `toml:"license"`
+			URLs                 map[string]string   `toml:"urls"`
+			Dependencies         []string            `toml:"dependencies"`
+			OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Name            string         `toml:"name"`
+				Description     string         `toml:"description"`
+				License         string         `toml:"license"`
+				Homepage        string         `toml:"homepage"`
+				Dependencies    map[string]any `toml:"dependencies"`
+				DevDependencies map[string]any `toml:"dev-dependencies"`
+				Group           map[string]struct {
+					Dependencies map[string]any `toml:"dependencies"`
+				} `toml:"group"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	if _, err := toml.Decode(string(contents), &pyprojectToml); err != nil {
+		return err
+	}
+
+	project, poetry := pyprojectToml.Project, pyprojectToml.Tool.Poetry
+	manifest.Name = firstNonEmpty(project.Name, poetry.Name)
+	manifest.Description = firstNonEmpty(project.Description, poetry.Description)
+	manifest.Homepage = poetry.Homepage
+	for key, url := range project.URLs {
+		if strings.EqualFold(key, "homepage") {
+			manifest.Homepage = url
+		}
+	}
+	// Either a SPDX expression, or {text = "..."} or {file = "..."}
+	switch license := project.License.(type) {
+	case string:
+		manifest.License = license
+	case map[string]any:
+		manifest.License, _ = license["text"].(string)
+	}
+	manifest.License = firstNonEmpty(manifest.License, poetry.License)
+
+	requirements := append([]string{}, project.Dependencies...)
+	for _, group := range project.OptionalDependencies {
+		requirements = append(requirements, group...)
+	}
+	for _, requirement := range requirements {
+		if groups := pythonRequirementName.FindStringSubmatch(requirement); groups != nil {
+			dependencies[groups[1]] = true
+		}
+	}
+
+	poetryGroups := []map[string]any{poetry.Dependencies, poetry.DevDependencies}
+	for _, group := range poetry.Group {
+		poetryGroups = append(poetryGroups, group.Dependencies)
+	}
+	for _, group := range poetryGroups {
+		for name := range group {
+			if name != "python" {
+				dependencies[name] = true
+			}
+		}
+	}
+	return nil
+}
+
+var gemDeclaration = regexp.MustCompile(`^\s*gem\s+["']([^"']+)["']`)
+
+func parseGemfile(contents []byte, dependencies map[string]bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		if groups := gemDeclaration.FindStringSubmatch(scanner.Text()); groups != nil {
+			dependencies[groups[1]] = true
+		}
+	}
+}
+
+// normalizePythonPackageName normalizes a package name as described in PEP 503, so that "Flask_SQLAlchemy" and "flask-sqlalchemy" are the same package.
+func normalizePythonPackageName(name string) string {
+	return strings.ToLower(regexp.MustCompile(`[-_.]+`).ReplaceAllString(name, "-"))
+}
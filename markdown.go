@@ -0,0 +1,207 @@
+package ortfodb
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	emoji "github.com/yuin/goldmark-emoji"
+	goldmarkHighlight "github.com/yuin/goldmark-highlighting"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"mvdan.cc/xurls/v2"
+)
+
+// DefaultMarkdownExtensions are the markdown extensions enabled when the configuration does not specify any.
+var DefaultMarkdownExtensions = []string{"footnote", "linkify", "strikethrough", "table", "typographer", "cjk", "highlighting"}
+
+// defaultAllowedTags are the HTML tags that survive sanitization. It covers everything that can be generated from markdown with any of the available extensions.
+var defaultAllowedTags = []string{
+	"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+	"em", "strong", "b", "i", "u", "small", "del", "s", "mark", "sup", "sub", "abbr", "kbd", "code", "pre", "span", "div",
+	"a", "img", "video", "audio", "source",
+	"ol", "ul", "li", "dl", "dt", "dd", "blockquote",
+	"table", "thead", "tbody", "tr", "th", "td",
+	"input", "figure", "figcaption", "details", "summary",
+}
+
+// defaultAllowedAttributes maps tag names to the HTML attributes that survive sanitization. "*" applies to every tag.
+var defaultAllowedAttributes = map[string][]string{
+	"*":      {"id", "class", "title", "lang", "dir", "role", "tabindex"},
+	"a":      {"href", "rel"},
+	"img":    {"src", "alt", "width", "height"},
+	"video":  {"src", "poster", "width", "height", "controls", "autoplay", "loop", "muted", "playsinline"},
+	"audio":  {"src", "controls", "autoplay", "loop", "muted"},
+	"source": {"src", "type"},
+	"ol":     {"start"},
+	"th":     {"align", "colspan", "rowspan"},
+	"td":     {"align", "colspan", "rowspan"},
+	"input":  {"checked", "disabled"},
+}
+
+// defaultAllowedStyles are the CSS properties allowed in style attributes, which are the ones table alignment and syntax highlighting use. Values are checked by the sanitizer, and properties that could move elements around the page, such as position, are removed.
+var defaultAllowedStyles = []string{"text-align", "color", "background-color", "font-weight", "font-style", "text-decoration"}
+
+var defaultMarkdownParser, _ = NewMarkdownParser(DefaultMarkdownExtensions)
+
+// normalizeExtensionName makes "Definition List", "definition-list" and "definition_list" equivalent.
+func normalizeExtensionName(name string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(name))
+}
+
+// NewMarkdownParser creates a markdown parser with the given extensions enabled. See DefaultMarkdownExtensions and MarkdownConfiguration.Extensions.
+func NewMarkdownParser(extensions []string) (goldmark.Markdown, error) {
+	if len(extensions) == 0 {
+		extensions = DefaultMarkdownExtensions
+	}
+
+	extenders := make([]goldmark.Extender, 0, len(extensions))
+	parserOptions := make([]parser.Option, 0)
+	for _, name := range extensions {
+		switch normalizeExtensionName(name) {
+		case "footnote", "footnotes":
+			extenders = append(extenders, extension.Footnote)
+		case "linkify":
+			extenders = append(extenders, extension.NewLinkify(
+				extension.WithLinkifyURLRegexp(xurls.Relaxed()),
+			))
+		case "strikethrough":
+			extenders = append(extenders, extension.Strikethrough)
+		case "table", "tables":
+			extenders = append(extenders, extension.Table)
+		case "typographer":
+			extenders = append(extenders, extension.Typographer)
+		case "cjk":
+			extenders = append(extenders, extension.CJK)
+		case "highlighting":
+			extenders = append(extenders, goldmarkHighlight.NewHighlighting())
+		case "definitionlist", "definitionlists":
+			extenders = append(extenders, extension.DefinitionList)
+		case "tasklist", "tasklists":
+			extenders = append(extenders, extension.TaskList)
+		case "emoji":
+			extenders = append(extenders, emoji.Emoji)
+		case "attributes", "attribute":
+			parserOptions = append(parserOptions, parser.WithAttribute())
+		default:
+			return nil, fmt.Errorf("unknown markdown extension %q", name)
+		}
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extenders...),
+		goldmark.WithParserOptions(parserOptions...),
+		goldmark.WithRendererOptions(
+			// Raw HTML is kept here, it goes through the sanitization policy afterwards.
+			html.WithUnsafe(),
+		),
+	), nil
+}
+
+// NewSanitizationPolicy creates the HTML sanitization policy described by the given configuration.
+// Returns nil if sanitization is disabled.
+func NewSanitizationPolicy(config SanitizationConfiguration) *bluemonday.Policy {
+	if config.Disabled {
+		return nil
+	}
+
+	policy := bluemonday.NewPolicy()
+	policy.AllowStandardURLs()
+	policy.RequireNoFollowOnLinks(false)
+	policy.AllowElements(defaultAllowedTags...)
+	policy.AllowElements(config.AllowedTags...)
+	policy.AllowStyles(defaultAllowedStyles...).Globally()
+	policy.AllowStyles("display").MatchingEnum("flex", "block", "inline", "inline-block").Globally()
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	for _, allowedAttributes := range []map[string][]string{defaultAllowedAttributes, config.AllowedAttributes} {
+		for tag, attributes := range allowedAttributes {
+			if tag == "*" {
+				policy.AllowAttrs(attributes...).Globally()
+			} else {
+				policy.AllowAttrs(attributes...).OnElements(tag)
+			}
+		}
+	}
+	return policy
+}
+
+// markdownParser returns the markdown parser configured for this run.
+func (ctx *RunContext) markdownParser() (goldmark.Markdown, error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.markdown == nil {
+		markdown, err := NewMarkdownParser(ctx.Config.Markdown.Extensions)
+		if err != nil {
+			return nil, err
+		}
+		ctx.markdown = markdown
+	}
+	return ctx.markdown, nil
+}
+
+// MarkdownToHTML converts markdown markdownRaw into an HTML string, using the markdown extensions enabled in the configuration.
+// The result is not sanitized, see SanitizeHTML.
+func (ctx *RunContext) MarkdownToHTML(markdownRaw string) (string, error) {
+	markdown, err := ctx.markdownParser()
+	if err != nil {
+		return "", fmt.Errorf("while setting up markdown parser: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(markdownRaw), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SanitizeHTML applies the configured sanitization policy to the given HTML.
+func (ctx *RunContext) SanitizeHTML(rawHTML HTMLString) HTMLString {
+	ctx.mu.Lock()
+	if ctx.sanitizer == nil && !ctx.Config.Markdown.Sanitization.Disabled {
+		ctx.sanitizer = NewSanitizationPolicy(ctx.Config.Markdown.Sanitization)
+	}
+	policy := ctx.sanitizer
+	ctx.mu.Unlock()
+
+	if policy == nil {
+		return rawHTML
+	}
+	return HTMLString(policy.Sanitize(withoutNonCheckboxInputs(string(rawHTML))))
+}
+
+// withoutNonCheckboxInputs removes input elements that are not checkboxes (from task lists), since the sanitization policy can only remove their attributes: browsers would show what is left as text fields.
+func withoutNonCheckboxInputs(rawHTML string) string {
+	var result strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(rawHTML))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == nethtml.ErrorToken {
+			return result.String()
+		}
+		raw := string(tokenizer.Raw())
+		if tokenType == nethtml.StartTagToken || tokenType == nethtml.SelfClosingTagToken {
+			token := tokenizer.Token()
+			if token.DataAtom == atom.Input && !slices.ContainsFunc(token.Attr, func(attr nethtml.Attribute) bool {
+				return attr.Key == "type" && strings.EqualFold(attr.Val, "checkbox")
+			}) {
+				continue
+			}
+		}
+		result.WriteString(raw)
+	}
+}
+
+// MarkdownToHTML converts markdown markdownRaw into an HTML string, using the default markdown extensions.
+func MarkdownToHTML(markdownRaw string) (string, error) {
+	var buf bytes.Buffer
+	if err := defaultMarkdownParser.Convert([]byte(markdownRaw), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
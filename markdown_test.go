@@ -0,0 +1,41 @@
+package ortfodb
+
+import "testing"
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "task list checkbox",
+			html: `<li><input checked="" disabled="" type="checkbox"> Done</li>`,
+			want: `<li><input checked="" disabled="" type="checkbox"> Done</li>`,
+		},
+		{
+			name: "text input",
+			html: `<p><input type="text" checked> <input value="x"/>Hi</p>`,
+			want: `<p> Hi</p>`,
+		},
+		{
+			name: "script and event handlers",
+			html: `<p onclick="alert(1)">Hi<script>alert(1)</script></p>`,
+			want: `<p>Hi</p>`,
+		},
+		{
+			name: "styles",
+			html: `<span style="color: red; position: fixed">Hi</span>`,
+			want: `<span style="color: red">Hi</span>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfiguration()
+			ctx := &RunContext{Config: &config}
+			if got := string(ctx.SanitizeHTML(HTMLString(tt.html))); got != tt.want {
+				t.Errorf("SanitizeHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,14 +2,10 @@ package ortfodb
 
 import (
 	"fmt"
-	"io/fs"
-	"os"
-	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
-	"sync"
 
-	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	ll "github.com/gwennlbh/label-logger-go"
 	"github.com/metal3d/go-slugify"
 	"gopkg.in/yaml.v2"
@@ -21,29 +17,46 @@ type autodetectData struct {
 
 	Files             []string
 	ContentConditions []string
+	Dependencies      []string
 }
 
 // Tag represents a category that can be assigned to a work. See https://ortfo.org/db/tags for more information.
 type Tag struct {
 	// Singular-form name of the tag. For example, "Book".
-	Singular string `yaml:"singular"`
+	Singular string `yaml:"singular" json:"singular"`
 	// Plural-form name of the tag. For example, "Books".
-	Plural      string `yaml:"plural"`
-	Description string `yaml:"description,omitempty"`
+	Plural      string `yaml:"plural" json:"plural"`
+	Description string `yaml:"description,omitempty" json:"description"`
 	// URL to a website where more information can be found about this tag.
 	LearnMoreAt string `yaml:"learn more at,omitempty" json:"learnMoreAt,omitempty"`
 	// Other singular-form names of tags that refer to this tag. The names mentionned here should not be used to define other tags.
-	Aliases []string `yaml:"aliases,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases"`
 	// Various ways to automatically detect that a work is tagged with this tag.
 	DetectConditions struct {
 		// Consider the work to be tagged with this tag if it contains any of the files specified here. Glob patterns are supported.
 		// Files are searched relative to the work's folder (even in Scattered mode, files are not searched relative to the .ortfo folder)
-		Files []string `yaml:"files,omitempty"`
-		// To be implemented
-		Search []string `yaml:"search,omitempty"`
+		Files []string `yaml:"files,omitempty" json:"files"`
+		// Consider the work to be tagged with this tag if any of the files searched contain one of the conditions specified here.
+		// Conditions are keywords, matched case-insensitively as whole words, or regular expressions written between slashes, optionally followed by flags: "/hello,? world/i".
+		Search []string `yaml:"search,omitempty" json:"search"`
+		// Files to match search conditions against, in addition to the description file. Glob patterns are supported, and files are searched relative to the work's folder.
+		// Defaults to README files at the root of the work's folder.
+		SearchIn []string `yaml:"search in,omitempty" json:"searchIn"`
 		// Consider the work to be tagged with this tag if it was made with any of the technologies specified here.
 		MadeWith []string `yaml:"made with,omitempty" json:"madeWith,omitempty"`
-	} `yaml:"detect,omitempty"`
+	} `yaml:"detect,omitempty" json:"detect"`
+	// Names and description to use instead for a given language. Maps language codes to translations.
+	Translations map[string]TagTranslation `yaml:"translations,omitempty" json:"translations"`
+	// Name of a broader tag that this tag belongs to. For example, "illustration" and "3D" could have "visual art" as their parent.
+	// Works tagged with this tag are also considered to be tagged with the parent tag (and its own parent, and so on).
+	Parent string `yaml:"parent,omitempty" json:"parent"`
+}
+
+// TagTranslation holds the names and description of a tag in a given language. Empty values mean that the tag's default one is used.
+type TagTranslation struct {
+	Singular    string `yaml:"singular,omitempty" json:"singular"`
+	Plural      string `yaml:"plural,omitempty" json:"plural"`
+	Description string `yaml:"description,omitempty" json:"description"`
 }
 
 func (t Tag) String() string {
@@ -58,42 +71,81 @@ func (t Tag) URLFriendlyName() string {
 	return slugify.Marshal(t.Singular, true)
 }
 
-func (t Tag) Detect(ctx *RunContext, workId string, techs []Technology) (bool, error) {
+// Localize returns the tag with its names and description in the given language, when they are translated.
+// The default names are added to the aliases, so that the localized tag is still referred to by them.
+func (t Tag) Localize(language string) Tag {
+	translation, ok := t.Translations[language]
+	if !ok {
+		return t
+	}
+	aliases := append([]string{}, t.Aliases...)
+	if translation.Singular != "" && translation.Singular != t.Singular {
+		aliases = append(aliases, t.Singular)
+		t.Singular = translation.Singular
+	}
+	if translation.Plural != "" && translation.Plural != t.Plural {
+		aliases = append(aliases, t.Plural)
+		t.Plural = translation.Plural
+	}
+	if translation.Description != "" {
+		t.Description = translation.Description
+	}
+	t.Aliases = aliases
+	return t
+}
+
+// Detect returns true if this tag is detected as applying to the work, along with the condition that was met.
+func (t Tag) Detect(ctx *RunContext, workId string, techs []Technology) (Detection, bool, error) {
 	for _, tech := range t.DetectConditions.MadeWith {
 		for _, candidate := range techs {
 			if candidate.ReferredToBy(tech) {
-				return true, nil
+				return Detection{Condition: "made with " + tech}, true, nil
 			}
 		}
 	}
-	return autodetectData{
-		Name:              t.Singular,
-		ContentConditions: t.DetectConditions.Search,
-		Files:             t.DetectConditions.Files,
+	detection, matched, err := autodetectData{
+		Name:  t.Singular,
+		Files: t.DetectConditions.Files,
 	}.Detect(ctx, workId)
+	if err != nil || matched {
+		return detection, matched, err
+	}
+	return t.detectSearch(ctx, workId)
 }
 
 // Technology represents a "technology" (in the very broad sense) that was used to create a work. See https://ortfo.org/db/technologies for more information.
 type Technology struct {
 	// The slug is a unique identifier for this technology, that's suitable for use in a website's URL.
 	// For example, the page that shows all works using a technology with slug "a" could be at https://example.org/technologies/a.
-	Slug string `yaml:"slug"`
-	Name string `yaml:"name"`
+	Slug string `yaml:"slug" json:"slug"`
+	Name string `yaml:"name" json:"name"`
 	// Name of the person or organization that created this technology.
-	By          string `yaml:"by,omitempty"`
-	Description string `yaml:"description,omitempty"`
+	By          string `yaml:"by,omitempty" json:"by"`
+	Description string `yaml:"description,omitempty" json:"description"`
 
 	// URL to a website where more information can be found about this technology.
 	LearnMoreAt string `yaml:"learn more at,omitempty" json:"learnMoreAt,omitempty"`
 
 	// Other technology slugs that refer to this technology. The slugs mentionned here should not be used in the definition of other technologies.
-	Aliases []string `yaml:"aliases,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty" json:"aliases"`
 
 	// Files contains a list of gitignore-style patterns. If the work contains any of the patterns specified, we consider that technology to be used in the work.
-	Files []string `yaml:"files,omitempty"`
+	Files []string `yaml:"files,omitempty" json:"files"`
 	// Autodetect contains an expression of the form 'CONTENT in PATH' where CONTENT is a free-form unquoted string and PATH is a filepath relative to the work folder.
 	// If CONTENT is found in PATH, we consider that technology to be used in the work.
-	Autodetect []string `yaml:"autodetect,omitempty"`
+	Autodetect []string `yaml:"autodetect,omitempty" json:"autodetect"`
+	// Dependencies contains names of packages that, when found in one of the work's manifest files (package.json, Cargo.toml, go.mod, pyproject.toml or Gemfile), mean that this technology is used in the work.
+	// Names can be prefixed with an ecosystem to only look in one kind of manifest: npm:svelte, cargo:tauri, go:github.com/spf13/cobra, pypi:django or rubygems:rails.
+	Dependencies []string `yaml:"dependencies,omitempty" json:"dependencies"`
+
+	// Name and description to use instead for a given language. Maps language codes to translations.
+	Translations map[string]TechnologyTranslation `yaml:"translations,omitempty" json:"translations"`
+}
+
+// TechnologyTranslation holds the name and description of a technology in a given language. Empty values mean that the technology's default one is used.
+type TechnologyTranslation struct {
+	Name        string `yaml:"name,omitempty" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description"`
 }
 
 func (t Technology) String() string {
@@ -108,149 +160,95 @@ func (t Technology) URLFriendlyName() string {
 	return t.Slug
 }
 
-func (t Technology) Detect(ctx *RunContext, workId string) (bool, error) {
+// Localize returns the technology with its name and description in the given language, when they are translated.
+// The default name is added to the aliases, so that the localized technology is still referred to by it.
+func (t Technology) Localize(language string) Technology {
+	translation, ok := t.Translations[language]
+	if !ok {
+		return t
+	}
+	if translation.Name != "" && translation.Name != t.Name {
+		t.Aliases = append(append([]string{}, t.Aliases...), t.Name)
+		t.Name = translation.Name
+	}
+	if translation.Description != "" {
+		t.Description = translation.Description
+	}
+	return t
+}
+
+// Detect returns true if this technology is detected as used in the work, along with the condition that was met.
+func (t Technology) Detect(ctx *RunContext, workId string) (Detection, bool, error) {
 	return autodetectData{
 		Name:              t.Slug,
 		ContentConditions: t.Autodetect,
 		Files:             t.Files,
+		Dependencies:      t.Dependencies,
 	}.Detect(ctx, workId)
 }
 
-// Detect returns true if this technology is detected as used in the work.
-func (t autodetectData) Detect(ctx *RunContext, workId string) (matched bool, err error) {
-	// Match files
-	contentDetectionConditions := make(map[string][]string)
-	contentDetectionFiles := make([]string, 0)
-	for _, f := range t.ContentConditions {
-		parts := strings.Split(f, " in ")
-		if len(parts) != 2 {
-			return false, fmt.Errorf("invalid autodetect expression: %s", f)
-		}
-		content := parts[0]
-		path := parts[1]
-		contentDetectionFiles = append(contentDetectionFiles, path)
-
-		if _, ok := contentDetectionConditions[path]; !ok {
-			contentDetectionConditions[path] = []string{content}
-		} else {
-			contentDetectionConditions[path] = append(contentDetectionConditions[path], content)
-		}
-	}
-	ll.Debug("Starting auto-detect for %s: contentDetection map is %v", t, contentDetectionConditions)
-	for _, f := range append(t.Files, contentDetectionFiles...) {
-		_, isContentDetection := contentDetectionConditions[f]
-		ll.Debug("Auto-detecting %s in %s: %q: isContentDetection=%v", t, workId, f, isContentDetection)
-		pat := gitignore.ParsePattern(f, nil)
-		// Walk all files of the work folder (excl. hidden files unfortunately)
-		err = fs.WalkDir(os.DirFS(ctx.PathToWorkFolder(workId)), ".", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			if filepath.Base(path) == "node_modules" {
-				return fs.SkipDir
-			}
-			if filepath.Base(path) == ".venv" {
-				return fs.SkipDir
-			}
-
-			pathFragments := make([]string, 0)
-			for _, fragment := range strings.Split(path, string(os.PathSeparator)) {
-				if fragment != "" {
-					pathFragments = append(pathFragments, fragment)
-				}
-			}
-
-			if isContentDetection {
-				if path != f {
-					return nil
-				}
-				contents, err := readFile(filepath.Join(ctx.PathToWorkFolder(workId), path))
-				if err != nil {
-					return fmt.Errorf("while reading contents of %s to check whether it contains one of %#v: %w", path, contentDetectionConditions[path], err)
-				}
-
-				for _, contentCondition := range contentDetectionConditions[path] {
-					if strings.Contains(contents, contentCondition) {
-						ll.Debug("Auto-detected %s in %s: condition %q in %q met", t, workId, contentCondition, path)
-						matched = true
-						return filepath.SkipAll
-					}
-				}
-
-			} else {
-				result := pat.Match(pathFragments, d.IsDir())
-				if result == gitignore.Exclude {
-					ll.Debug("Auto-detected %s in %s: filepattern %q matches %q", t, workId, f, path)
-					matched = true
-					return filepath.SkipAll
-				} else if result == gitignore.Include {
-					ll.Debug("Auto-detected %s in %s: filepattern %q matches %q", t, workId, f, path)
-					matched = false
-					return filepath.SkipAll
-				}
-			}
-
-			return nil
-		})
+// Detect returns true if any of the conditions is met in the work, along with the first condition that was met.
+// Files are checked first, then content conditions, then dependencies.
+func (t autodetectData) Detect(ctx *RunContext, workId string) (detection Detection, matched bool, err error) {
+	scan, err := ctx.scanWork(workId)
+	if err != nil {
+		return Detection{}, false, err
 	}
-	return
-}
-
-func (ctx *RunContext) DetectTechnologies(workId string) (detecteds []Technology, err error) {
-	results := make(chan Technology, len(ctx.TechnologiesRepository))
-	errs := make(chan error, len(ctx.TechnologiesRepository))
-	wg := sync.WaitGroup{}
 
-	for _, tech := range ctx.TechnologiesRepository {
-		wg.Add(1)
-		go func(tech Technology, results chan Technology, errors chan error, wg *sync.WaitGroup) {
-			matched, err := tech.Detect(ctx, workId)
-			if err != nil {
-				errors <- fmt.Errorf("while trying to detect %s: %w", tech, err)
-			}
-			if matched {
-				results <- tech
-			}
-			wg.Done()
-		}(tech, results, errs, &wg)
+	if detection, matched := scan.match(t.Files); matched {
+		ll.Debug("Auto-detected %s in %s: filepattern %q matches %q", t.Name, workId, detection.Condition, detection.Path)
+		return detection, true, nil
 	}
 
-	wg.Wait()
-	close(results)
-	close(errs)
-
-	for err := range errs {
+	for _, condition := range t.ContentConditions {
+		content, path, found := strings.Cut(condition, " in ")
+		if !found {
+			return Detection{}, false, fmt.Errorf("invalid autodetect expression: %s", condition)
+		}
+		contents, ok, err := scan.read(path)
 		if err != nil {
-			return detecteds, err
+			return Detection{}, false, fmt.Errorf("while reading contents of %s to check whether it contains %q: %w", path, content, err)
+		}
+		if ok && strings.Contains(contents, content) {
+			ll.Debug("Auto-detected %s in %s: condition %q in %q met", t.Name, workId, content, path)
+			return Detection{Condition: condition, Path: path}, true, nil
 		}
 	}
 
-	for tech := range results {
-		detecteds = append(detecteds, tech)
+	for _, dependency := range t.Dependencies {
+		for _, manifest := range scan.manifests {
+			if manifest.DependsOn(dependency) {
+				ll.Debug("Auto-detected %s in %s: %s depends on %s", t.Name, workId, manifest.Path, dependency)
+				return Detection{Condition: dependency, Path: manifest.Path}, true, nil
+			}
+		}
 	}
 
-	sort.Slice(detecteds, func(i, j int) bool {
-		return detecteds[i].Slug < detecteds[j].Slug
-	})
-
-	return
+	return Detection{}, false, nil
 }
 
+// ReferredToBy returns true if name is one of the tag's names or aliases, in any language.
 func (t Tag) ReferredToBy(name string) bool {
-	return stringsLooselyMatch(name, append(t.Aliases, t.Plural, t.Singular)...)
+	names := append([]string{t.Plural, t.Singular}, t.Aliases...)
+	for _, translation := range t.Translations {
+		names = append(names, translation.Singular, translation.Plural)
+	}
+	names = slices.DeleteFunc(names, func(name string) bool { return name == "" })
+	return stringsLooselyMatch(name, names...)
 }
 
 func (ctx *RunContext) FindTag(name string) (result Tag, ok bool) {
-	for _, tag := range ctx.TagsRepository {
-		if tag.ReferredToBy(name) {
-			return tag, true
-		}
-	}
-	return Tag{}, false
+	return findTag(ctx.TagsRepository, name)
 }
 
+// ReferredToBy returns true if name is the technology's slug, or one of its names or aliases, in any language.
 func (t Technology) ReferredToBy(name string) bool {
-	return stringsLooselyMatch(name, append(t.Aliases, t.Slug, t.Name)...)
+	names := append([]string{t.Slug, t.Name}, t.Aliases...)
+	for _, translation := range t.Translations {
+		names = append(names, translation.Name)
+	}
+	names = slices.DeleteFunc(names, func(name string) bool { return name == "" })
+	return stringsLooselyMatch(name, names...)
 }
 
 func (ctx *RunContext) FindTechnology(name string) (result Technology, ok bool) {
@@ -262,37 +260,36 @@ func (ctx *RunContext) FindTechnology(name string) (result Technology, ok bool)
 	return Technology{}, false
 }
 
-func (ctx *RunContext) DetectTags(workId string, techs []Technology) (detecteds []Tag, err error) {
-	results := make(chan Tag, len(ctx.TagsRepository))
-	errs := make(chan error, len(ctx.TagsRepository))
-	wg := sync.WaitGroup{}
-
-	for _, tag := range ctx.TagsRepository {
-		wg.Add(1)
-		go func(tag Tag, results chan Tag, errors chan error, wg *sync.WaitGroup) {
-			matched, err := tag.Detect(ctx, workId, techs)
-			if err != nil {
-				errors <- fmt.Errorf("while trying to detect %s: %w", tag, err)
-			}
-			if matched {
-				results <- tag
-			}
-			wg.Done()
-		}(tag, results, errs, &wg)
+// DetectTechnologies returns the technologies of the repository that are detected as used in the work, along with the condition that was met for each of them.
+// The work's folder is only walked once for all technologies, see DetectionConfiguration for files that are ignored.
+func (ctx *RunContext) DetectTechnologies(workId string) (detecteds []DetectedTechnology, err error) {
+	for _, tech := range ctx.TechnologiesRepository {
+		detection, matched, err := tech.Detect(ctx, workId)
+		if err != nil {
+			return detecteds, fmt.Errorf("while trying to detect %s: %w", tech, err)
+		}
+		if matched {
+			detecteds = append(detecteds, DetectedTechnology{Technology: tech, Detection: detection})
+		}
 	}
 
-	wg.Wait()
-	close(results)
-	close(errs)
+	sort.Slice(detecteds, func(i, j int) bool {
+		return detecteds[i].Slug < detecteds[j].Slug
+	})
+
+	return
+}
 
-	for err := range errs {
+// DetectTags returns the tags of the repository that are detected as applying to the work, along with the condition that was met for each of them.
+func (ctx *RunContext) DetectTags(workId string, techs []Technology) (detecteds []DetectedTag, err error) {
+	for _, tag := range ctx.TagsRepository {
+		detection, matched, err := tag.Detect(ctx, workId, techs)
 		if err != nil {
-			return detecteds, err
+			return detecteds, fmt.Errorf("while trying to detect %s: %w", tag, err)
+		}
+		if matched {
+			detecteds = append(detecteds, DetectedTag{Tag: tag, Detection: detection})
 		}
-	}
-
-	for tag := range results {
-		detecteds = append(detecteds, tag)
 	}
 
 	sort.Slice(detecteds, func(i, j int) bool {
@@ -322,10 +319,84 @@ func (ctx *RunContext) LoadTagsRepository() ([]Tag, error) {
 		return []Tag{}, fmt.Errorf("while decoding YAML: %w", err)
 	}
 
+	err = resolveTagParents(tags)
+	if err != nil {
+		return []Tag{}, fmt.Errorf("while checking tags hierarchy in %s: %w", ctx.Config.Tags.Repository, err)
+	}
+
+	for _, tag := range tags {
+		for _, condition := range tag.DetectConditions.Search {
+			if _, err := ParseSearchCondition(condition); err != nil {
+				return []Tag{}, fmt.Errorf("while checking detect conditions of tag %s in %s: %w", tag, ctx.Config.Tags.Repository, err)
+			}
+		}
+	}
+
 	ctx.TagsRepository = tags
 	return tags, nil
 }
 
+// resolveTagParents replaces the parents of tags with the singular name of the tag they refer to.
+// An error is returned if a parent is not in tags, or if tags are their own ancestors.
+func resolveTagParents(tags []Tag) error {
+	for i, tag := range tags {
+		if tag.Parent == "" {
+			continue
+		}
+		parent, ok := findTag(tags, tag.Parent)
+		if !ok {
+			return fmt.Errorf("tag %s has %s as its parent, which is not a tag", tag, tag.Parent)
+		}
+		tags[i].Parent = parent.Singular
+	}
+
+	for _, tag := range tags {
+		ancestors := []string{tag.Singular}
+		for current := tag; current.Parent != ""; {
+			current, _ = findTag(tags, current.Parent)
+			ancestors = append(ancestors, current.Singular)
+			if current.Singular == tag.Singular {
+				return fmt.Errorf("tag %s is its own ancestor (%s)", tag, strings.Join(ancestors, " → "))
+			}
+			if len(ancestors) > len(tags)+1 {
+				// Cycle further up, it will be reported for one of the tags that are in it
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func findTag(tags []Tag, name string) (Tag, bool) {
+	for _, tag := range tags {
+		if tag.ReferredToBy(name) {
+			return tag, true
+		}
+	}
+	return Tag{}, false
+}
+
+// TagChildren returns the tags of the tags repository that have the given tag as their parent.
+func (ctx *RunContext) TagChildren(tag Tag) []Tag {
+	children := make([]Tag, 0)
+	for _, candidate := range ctx.TagsRepository {
+		if candidate.Parent != "" && tag.ReferredToBy(candidate.Parent) {
+			children = append(children, candidate)
+		}
+	}
+	return children
+}
+
+// TagDescendants returns the children of the given tag, their own children, and so on.
+func (ctx *RunContext) TagDescendants(tag Tag) []Tag {
+	descendants := make([]Tag, 0)
+	for _, child := range ctx.TagChildren(tag) {
+		descendants = append(descendants, child)
+		descendants = append(descendants, ctx.TagDescendants(child)...)
+	}
+	return descendants
+}
+
 func (ctx *RunContext) LoadTechnologiesRepository() ([]Technology, error) {
 	if len(ctx.TechnologiesRepository) > 0 {
 		return ctx.TechnologiesRepository, nil
@@ -349,3 +420,150 @@ func (ctx *RunContext) LoadTechnologiesRepository() ([]Technology, error) {
 	ctx.TechnologiesRepository = technologies
 	return technologies, nil
 }
+
+// TagUsage is a tag of the tags repository, along with the number of works that are tagged with it and its children.
+type TagUsage struct {
+	Tag
+	// Number of works tagged with this tag or one of its descendants.
+	WorksCount int `json:"worksCount"`
+	// Singular names of the tags that have this tag as their parent.
+	Children []string `json:"children"`
+}
+
+// TechnologyUsage is a technology of the technologies repository, along with the number of works that were made with it.
+type TechnologyUsage struct {
+	Technology
+	WorksCount int `json:"worksCount"`
+}
+
+// CanonicalizeTagsAndTechnologies replaces the tags and technologies of every work (including localized tags) with the name of the repository entry they refer to: the singular name for tags, and the slug for technologies. The entries are set on the work too, see Work.Tags and Work.Technologies.
+// Tags and technologies that are not in their repository are kept as-is, and a warning is shown. Nothing is done for tags (or technologies) when there is no tags (or technologies) repository.
+func (ctx *RunContext) CanonicalizeTagsAndTechnologies(works Database) error {
+	if ctx.Config.Tags.Repository != "" {
+		if _, err := ctx.LoadTagsRepository(); err != nil {
+			return fmt.Errorf("while loading tags repository: %w", err)
+		}
+	}
+	if ctx.Config.Technologies.Repository != "" {
+		if _, err := ctx.LoadTechnologiesRepository(); err != nil {
+			return fmt.Errorf("while loading technologies repository: %w", err)
+		}
+	}
+
+	for _, workID := range sortedKeys(works) {
+		work := works[workID]
+		work.Metadata.Tags = ctx.canonicalTags(workID, work.Metadata.Tags)
+		for language, localized := range work.Metadata.Localized {
+			localized.Tags = ctx.canonicalTags(workID, localized.Tags)
+			work.Metadata.Localized[language] = localized
+		}
+		// Tags of localized contents were warned about above already
+		for language, content := range work.Content {
+			if len(ctx.TagsRepository) == 0 || content.Metadata.Tags == nil {
+				continue
+			}
+			tags := make([]string, 0, len(content.Metadata.Tags))
+			for _, name := range content.Metadata.Tags {
+				if tag, ok := ctx.FindTag(name); ok {
+					name = tag.Singular
+				}
+				if !stringInSlice(tags, name) {
+					tags = append(tags, name)
+				}
+			}
+			content.Metadata.Tags = tags
+			work.Content[language] = content
+		}
+		if len(ctx.TechnologiesRepository) > 0 {
+			for i, name := range work.Metadata.MadeWith {
+				if technology, ok := ctx.FindTechnology(name); ok {
+					work.Metadata.MadeWith[i] = technology.Slug
+				} else {
+					ll.Warn("Work %s is made with %q, which is not in the technologies repository", workID, name)
+				}
+			}
+		}
+		work.Tags = make([]Tag, 0, len(work.Metadata.Tags))
+		for _, name := range work.Metadata.AllTags() {
+			tag, ok := ctx.FindTag(name)
+			if !ok {
+				tag = Tag{Singular: name}
+			}
+			work.Tags = append(work.Tags, tag)
+		}
+		work.Technologies = make([]Technology, 0, len(work.Metadata.MadeWith))
+		for _, name := range work.Metadata.MadeWith {
+			technology, ok := ctx.FindTechnology(name)
+			if !ok {
+				technology = Technology{Slug: name}
+			}
+			work.Technologies = append(work.Technologies, technology)
+		}
+		works[workID] = work
+	}
+	return nil
+}
+
+func (ctx *RunContext) canonicalTags(workID string, names []string) []string {
+	if len(ctx.TagsRepository) == 0 || names == nil {
+		return names
+	}
+	canonical := make([]string, 0, len(names))
+	for _, name := range names {
+		if tag, ok := ctx.FindTag(name); ok {
+			name = tag.Singular
+		} else {
+			ll.Warn("Work %s is tagged with %q, which is not in the tags repository", workID, name)
+		}
+		if !stringInSlice(canonical, name) {
+			canonical = append(canonical, name)
+		}
+	}
+	return canonical
+}
+
+// TagsUsage returns all tags of the tags repository, with their children and the number of the given works that are tagged with each of them (or one of their descendants), in any language.
+func (ctx *RunContext) TagsUsage(works Database) []TagUsage {
+	usages := make([]TagUsage, 0, len(ctx.TagsRepository))
+	for _, tag := range ctx.TagsRepository {
+		usage := TagUsage{Tag: tag, Children: make([]string, 0)}
+		for _, child := range ctx.TagChildren(tag) {
+			usage.Children = append(usage.Children, child.Singular)
+		}
+		tags := append([]Tag{tag}, ctx.TagDescendants(tag)...)
+		for _, work := range works {
+			if some(work.Metadata.AllTags(), func(name string) bool { return some(tags, func(t Tag) bool { return t.ReferredToBy(name) }) }) {
+				usage.WorksCount++
+			}
+		}
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+// TechnologiesUsage returns all technologies of the technologies repository, with the number of the given works that were made with each of them.
+func (ctx *RunContext) TechnologiesUsage(works Database) []TechnologyUsage {
+	usages := make([]TechnologyUsage, 0, len(ctx.TechnologiesRepository))
+	for _, technology := range ctx.TechnologiesRepository {
+		usage := TechnologyUsage{Technology: technology}
+		for _, work := range works {
+			if some(work.Metadata.MadeWith, technology.ReferredToBy) {
+				usage.WorksCount++
+			}
+		}
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+// Localize returns the tag in the given language, see Tag.Localize.
+func (t TagUsage) Localize(language string) TagUsage {
+	t.Tag = t.Tag.Localize(language)
+	return t
+}
+
+// Localize returns the technology in the given language, see Technology.Localize.
+func (t TechnologyUsage) Localize(language string) TechnologyUsage {
+	t.Technology = t.Technology.Localize(language)
+	return t
+}
@@ -0,0 +1,90 @@
+package ortfodb
+
+import (
+	"fmt"
+	"slices"
+)
+
+// WorkRelations lists other works that a work is related to, by type of relation. Works are referred to by their ID or one of their aliases in description files, and by their ID in the database.
+type WorkRelations struct {
+	// Works that are related to this one, without any more specific meaning.
+	Related []string `json:"related" yaml:",omitempty"`
+	// Works that this one is a sequel of.
+	SequelOf []string `json:"sequelOf" yaml:"sequel of,omitempty"`
+	// Works that this one is a part of.
+	PartOf []string `json:"partOf" yaml:"part of,omitempty"`
+	// Works that this one uses, for example a game engine or a library made for another work.
+	Uses []string `json:"uses" yaml:",omitempty"`
+}
+
+// RelationTypes lists the types of relations, as written in description files.
+var RelationTypes = []string{"related", "sequel of", "part of", "uses"}
+
+func (r *WorkRelations) byType() map[string]*[]string {
+	return map[string]*[]string{
+		"related":   &r.Related,
+		"sequel of": &r.SequelOf,
+		"part of":   &r.PartOf,
+		"uses":      &r.Uses,
+	}
+}
+
+// Empty returns true if there are no relations at all.
+func (r WorkRelations) Empty() bool {
+	return len(r.Related) == 0 && len(r.SequelOf) == 0 && len(r.PartOf) == 0 && len(r.Uses) == 0
+}
+
+// All returns the IDs of all works that are in any of the relations, without duplicates, in the order of RelationTypes.
+// Useful to render a "see also" section.
+func (r WorkRelations) All() []string {
+	all := make([]string, 0)
+	for _, relationType := range RelationTypes {
+		for _, workID := range *r.byType()[relationType] {
+			if !slices.Contains(all, workID) {
+				all = append(all, workID)
+			}
+		}
+	}
+	return all
+}
+
+// ResolveRelations checks that the relations of every work refer to existing works, replaces aliases with work IDs, and computes the backlinks of every work.
+// An error is returned if a work is related to a work that does not exist, or to itself.
+func (ctx *RunContext) ResolveRelations(works Database) error {
+	for _, workID := range sortedKeys(works) {
+		work := works[workID]
+		resolved := WorkRelations{}
+		for _, relationType := range RelationTypes {
+			targets := work.Metadata.Relations.byType()[relationType]
+			for _, idOrAlias := range *targets {
+				target, found := works.FindWork(idOrAlias)
+				if !found {
+					return fmt.Errorf("work %s has a %q relation to %s, which does not exist", workID, relationType, idOrAlias)
+				}
+				if target.ID == workID {
+					return fmt.Errorf("work %s has a %q relation to itself", workID, relationType)
+				}
+				if !slices.Contains(*resolved.byType()[relationType], target.ID) {
+					*resolved.byType()[relationType] = append(*resolved.byType()[relationType], target.ID)
+				}
+			}
+		}
+		work.Metadata.Relations = resolved
+		work.Backlinks = WorkRelations{}
+		works[workID] = work
+	}
+
+	for _, workID := range sortedKeys(works) {
+		relations := works[workID].Metadata.Relations
+		for _, relationType := range RelationTypes {
+			for _, targetID := range *relations.byType()[relationType] {
+				target := works[targetID]
+				backlinks := target.Backlinks.byType()[relationType]
+				*backlinks = append(*backlinks, workID)
+				works[targetID] = target
+			}
+		}
+	}
+
+	return nil
+}
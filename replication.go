@@ -1,106 +1,213 @@
 package ortfodb
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	html2md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/anaskhan96/soup"
 	ll "github.com/gwennlbh/label-logger-go"
-	"github.com/mitchellh/mapstructure"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	// Copy media files from the media directory next to the replicated description files.
+	ReplicateMediaCopy = "copy"
+	// Create symbolic links to media files of the media directory next to the replicated description files.
+	ReplicateMediaLink = "link"
+	// Don't replicate media files.
+	ReplicateMediaNone = "none"
+)
+
+// ReplicateOptions configures how works are replicated by ReplicateAll and ReplicateOne.
+type ReplicateOptions struct {
+	// What to do with media files: one of ReplicateMediaCopy, ReplicateMediaLink or ReplicateMediaNone. Media files are taken from the media directory (see MediaConfiguration.At).
+	Media string
+	// Parse replicated description files again and make sure that they describe the same works, see VerifyReplication.
+	Verify bool
+}
+
 // ReplicateAll recreates a database inside targetDatabase containing all the works in works.
-func (ctx *RunContext) ReplicateAll(targetDatabase string, works Database) error {
-	for _, work := range works {
-		err := ctx.ReplicateOne(targetDatabase, work)
+// Works that could not be replicated are reported, and the other ones are still replicated.
+func (ctx *RunContext) ReplicateAll(targetDatabase string, works Database, options ReplicateOptions) error {
+	if !slices.Contains([]string{"", ReplicateMediaCopy, ReplicateMediaLink, ReplicateMediaNone}, options.Media) {
+		return fmt.Errorf("unknown media replication mode %q, use one of %s, %s or %s", options.Media, ReplicateMediaCopy, ReplicateMediaLink, ReplicateMediaNone)
+	}
+
+	failed := 0
+	for _, id := range sortedKeys(works) {
+		err := ctx.ReplicateOne(targetDatabase, works[id], options)
 		if err != nil {
-			return err
+			ll.ErrorDisplay("while replicating %s", err, id)
+			failed++
 		}
 	}
+	if failed > 0 {
+		return fmt.Errorf("could not replicate %d of %d works", failed, len(works))
+	}
 	return nil
 }
 
-// ReplicateOne creates a description.md file in targetDatabase in the correct folder in order to replicate Work.
-func (ctx *RunContext) ReplicateOne(targetDatabase string, work Work) error {
-	//TODO: make file mode configurable
-	workDirectory := path.Join(targetDatabase, work.ID)
-	os.MkdirAll(workDirectory, os.FileMode(0o0777))
+// ReplicateOne creates a description file in targetDatabase in the correct folder in order to replicate Work, along with its media files.
+func (ctx *RunContext) ReplicateOne(targetDatabase string, work Work, options ReplicateOptions) error {
+	descriptionFilename := ctx.DescriptionFilename(targetDatabase, work.ID)
+	err := os.MkdirAll(filepath.Dir(descriptionFilename), 0o755)
+	if err != nil {
+		return fmt.Errorf("while creating folder for description file: %w", err)
+	}
+
 	description, err := ctx.ReplicateDescription(work)
 	if err != nil {
-		return fmt.Errorf("while replicating %s: %w", work.ID, err)
+		return err
+	}
+
+	err = os.WriteFile(descriptionFilename, []byte(description), 0o644)
+	if err != nil {
+		return fmt.Errorf("while writing description file: %w", err)
 	}
 
-	os.WriteFile(path.Join(workDirectory, "description.md"), []byte(description), os.FileMode(0o0777))
+	if options.Media != "" && options.Media != ReplicateMediaNone {
+		err = ctx.replicateMediaFiles(filepath.Join(targetDatabase, work.ID), filepath.Dir(descriptionFilename), work, options.Media)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Verify {
+		err = ctx.VerifyReplication(work, description)
+		if err != nil {
+			return err
+		}
+	}
+
+	ll.Log("Replicated", "green", "%s to %s", work.ID, descriptionFilename)
+	return nil
+}
+
+// replicateMediaFiles copies or links (depending on mode) media files of work from the media directory to where the description file references them.
+func (ctx *RunContext) replicateMediaFiles(workFolder string, descriptionFolder string, work Work, mode string) error {
+	replicated := make([]string, 0)
+	for _, language := range sortedKeys(work.Content) {
+		for _, block := range work.Content[language].Blocks {
+			if !block.Type.IsMedia() || block.DistSource == "" || block.Online || isValidURL(string(block.RelativeSource)) || slices.Contains(replicated, string(block.RelativeSource)) {
+				continue
+			}
+			replicated = append(replicated, string(block.RelativeSource))
+
+			source := block.DistSource.Absolute(ctx)
+			destination := filepath.Join(descriptionFolder, string(block.RelativeSource))
+			if relative, err := filepath.Rel(workFolder, destination); err != nil || strings.HasPrefix(relative, "..") {
+				ll.Warn("%s: not replicating media file %s, which is outside of the work's folder", work.ID, block.RelativeSource)
+				continue
+			}
+			if fileExists(destination) {
+				ll.Debug("%s: media file %s already exists", work.ID, destination)
+				continue
+			}
+
+			err := os.MkdirAll(filepath.Dir(destination), 0o755)
+			if err != nil {
+				return fmt.Errorf("while creating folder for media file %s: %w", block.RelativeSource, err)
+			}
+			switch mode {
+			case ReplicateMediaCopy:
+				err = copyFile(source, destination)
+			case ReplicateMediaLink:
+				err = os.Symlink(source, destination)
+			}
+			if err != nil {
+				return fmt.Errorf("while replicating media file %s: %w", block.RelativeSource, err)
+			}
+		}
+	}
 	return nil
 }
 
 // ReplicateDescription reconstructs the contents of a description.md file from a Work struct.
+// The result is deterministic: replicating the work described by the result gives the same result again.
 func (ctx *RunContext) ReplicateDescription(work Work) (string, error) {
 	var result string
 	// Start with the YAML header, this one is never localized
-	yamlHeader, err := ctx.replicateMetadata(work.Metadata)
+	yamlHeader, err := ctx.replicateMetadata(work)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("while replicating metadata: %w", err)
 	}
-	result += yamlHeader + "\n"
-	// TODO get rid of "default" language behavior
-	// if a file has NO language markers, auto-insert ":: (machine's language)" before parsing.
-	for language := range work.Content {
-		result += ctx.replicateLanguageMarker(language) + "\n\n"
+	result += yamlHeader + "\n\n"
+
+	languages := sortedKeys(work.Content)
+	// Works without language markers only have a "default" language
+	localized := !(len(languages) == 1 && languages[0] == "default")
+	for _, language := range languages {
+		if localized {
+			result += ctx.replicateLanguageMarker(language) + "\n\n"
+		}
 		replicatedBlock, err := ctx.replicateLocalizedBlock(work, language)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("while replicating %s content: %w", language, err)
 		}
-		result += replicatedBlock + "\n\n"
+		result += replicatedBlock
 	}
-	return strings.TrimSpace(result), nil
+	return strings.TrimSpace(result) + "\n", nil
 }
 
 func (ctx *RunContext) replicateLocalizedBlock(work Work, language string) (string, error) {
 	var result string
 	end := "\n\n"
 	content := work.Content[language]
-	// Abbreviations will be stored here to declare them in the markdown
-	abbreviations := make(Abbreviations)
 	// Start with the title
 	if content.Title != "" {
 		result += ctx.replicateTitle(content.Title) + end
 	}
-	// Then, for each block (ordered by the layout)
-	// spew.Dump(work)
+	// Then, each block, in the order of the description file, with links to other works as they were written
 	for _, block := range content.Blocks {
+		block = unresolveWorkLinks(block)
 		ll.Debug("replicating %s block #%s", block.Type, block.ID)
+		if block.Name != "" && !block.isAutomaticallyNamed() {
+			result += "{#" + block.Name + "}\n"
+		}
 		switch block.Type {
 		case "media":
 			result += ctx.replicateMediaEmbed(block.Media) + end
 		case "link":
 			result += ctx.replicateLink(block.Link) + end
 		case "paragraph":
-			replicatedParagraph, err := ctx.replicateParagraph(block.Anchor, block.Paragraph)
+			replicatedParagraph, err := ctx.replicateParagraph(block.Paragraph)
 			if err != nil {
 				return "", err
 			}
-			// This is not finished: we need to properly translate to markdown abbreviations & footnotes
-			parsedHTML := soup.HTMLParse(string(block.Content))
-			abbreviations = merge(abbreviations, ctx.collectAbbreviations(parsedHTML))
-			replicatedParagraph = ctx.transformAbbreviations(parsedHTML, replicatedParagraph)
-			replicatedParagraph = ctx.transformFootnoteReferences(replicatedParagraph)
 			result += replicatedParagraph + end
 		default: // nothing
 		}
 	}
-	for name, content := range content.Footnotes {
-		result += ctx.replicateFootnoteDefinition(name, string(content)) + end
+	for _, name := range sortedFootnoteNames(content.Footnotes) {
+		result += ctx.replicateFootnoteDefinition(name, content.Footnotes[name]) + end
 	}
-	result += ctx.replicateAbbreviations(abbreviations)
+	result += ctx.replicateAbbreviations(content.Abbreviations)
 	return result, nil
 }
 
+// isAutomaticallyNamed returns true if the block is a heading that got its name from its anchor, and thus does not need a {#name} marker.
+func (b ContentBlock) isAutomaticallyNamed() bool {
+	groups := regexp.MustCompile(`^<h[2-6] id="([^"]+)"`).FindStringSubmatch(string(b.Content))
+	return b.isHeading() && groups != nil && groups[1] == b.Name
+}
+
+// sortedFootnoteNames returns footnote names in numeric order if they are numbers, which is the case for built works.
+func sortedFootnoteNames(footnotes Footnotes) []string {
+	names := sortedKeys(footnotes)
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i]) < len(names[j])
+	})
+	return names
+}
+
 func (ctx *RunContext) replicateLanguageMarker(language string) string {
 	return ":: " + language
 }
@@ -120,57 +227,55 @@ func (ctx *RunContext) transformFootnoteReferences(markdown string) string {
 	return transformedMarkdown
 }
 
-// Remove markup from abbreviations.
-func (ctx *RunContext) transformAbbreviations(htmlSoup soup.Root, markdown string) string {
-	transformedMarkdown := markdown
+// removeAbbreviations removes abbreviation markup, which is added back from the abbreviation definitions when building.
+func (ctx *RunContext) removeAbbreviations(htmlSoup soup.Root, html string) string {
 	for _, abbr := range htmlSoup.FindAll("abbr") {
-		transformedMarkdown = strings.ReplaceAll(transformedMarkdown, abbr.HTML(), abbr.FullText())
+		html = strings.ReplaceAll(html, abbr.HTML(), abbr.FullText())
 	}
-	return transformedMarkdown
-}
-
-func (ctx *RunContext) collectAbbreviations(htmlSoup soup.Root) Abbreviations {
-	abbreviations := make(Abbreviations)
-	for _, abbr := range htmlSoup.FindAll("abbr") {
-		abbreviations[abbr.FullText()] = abbr.Attrs()["title"]
-	}
-	return abbreviations
+	return html
 }
 
-// We replicate all abbreviations in one function to avoid duplicates.
+// replicateAbbreviations writes abbreviation definitions, sorted by name. Each definition is its own block.
 func (ctx *RunContext) replicateAbbreviations(abbreviations Abbreviations) string {
 	var result string
-	// Stores all the alread-replicated abbreviations' names (to handle duplicates)
-	replicated := make([]string, 0, len(abbreviations))
-	for name, definition := range abbreviations {
-		if stringInSlice(replicated, name) {
-			continue
-		}
-		result += "*[" + name + "]: " + definition + "\n"
-		replicated = append(replicated, definition)
+	for _, name := range sortedKeys(abbreviations) {
+		result += "*[" + name + "]: " + abbreviations[name] + "\n\n"
 	}
 	return result
 }
 
-func (ctx *RunContext) replicateFootnoteDefinition(name string, content string) string {
-	return "[^" + name + "]: " + content
+// footnoteBackReference matches the link back to the reference that ends footnotes.
+var footnoteBackReference = regexp.MustCompile(`(\x{00a0}|&nbsp;)?<a href="#fnref:[^"]*"[^>]*>[^<]*</a>`)
+
+func (ctx *RunContext) replicateFootnoteDefinition(name string, content HTMLString) string {
+	markdown := HTMLString(footnoteBackReference.ReplaceAllString(string(content), "")).Markdown()
+	// Paragraphs after the first one are indented to be part of the footnote
+	return "[^" + name + "]: " + strings.ReplaceAll(markdown, "\n", "\n    ")
 }
 
 func (ctx *RunContext) replicateLink(link Link) string {
 	if link.Title != "" {
-		return "[" + link.Text.String() + `](` + link.URL + ` "` + link.Title + `")`
+		return "[" + link.Text.Markdown() + `](` + link.URL + ` "` + link.Title + `")`
 	}
-	return "[" + link.Text.String() + "](" + link.URL + ")"
+	return "[" + link.Text.Markdown() + "](" + link.URL + ")"
 }
 
 func (ctx *RunContext) replicateTitle(title HTMLString) string {
 	return "# " + title.Markdown()
 }
 
-func (ctx *RunContext) replicateMetadata(metadata WorkMetadata) (string, error) {
-	metadataOut := make(map[string]interface{})
-	mapstructure.Decode(metadata, &metadataOut)
-	yamlBytes, err := yaml.Marshal(metadataOut)
+// replicateMetadata writes the work's metadata as a YAML front matter, including its layouts. Tags and technologies that were detected instead of declared (see Work.Detected) are left out.
+func (ctx *RunContext) replicateMetadata(work Work) (string, error) {
+	metadata := work.Metadata
+	metadata.MadeWith = slices.DeleteFunc(slices.Clone(metadata.MadeWith), func(tech string) bool {
+		_, detected := work.Detected.MadeWith[tech]
+		return detected
+	})
+	metadata.Tags = slices.DeleteFunc(slices.Clone(metadata.Tags), func(tag string) bool {
+		_, detected := work.Detected.Tags[tag]
+		return detected
+	})
+	yamlBytes, err := yaml.Marshal(metadata)
 	if err != nil {
 		return "", err
 	}
@@ -193,32 +298,196 @@ func (ctx *RunContext) replicateMediaAttributesString(attributes MediaAttributes
 
 // TODO: configure whether to use >[]() syntax: never, or only for non-images
 func (ctx *RunContext) replicateMediaEmbed(media Media) string {
+	alt := media.Alt
+	if attributes := ctx.replicateMediaAttributesString(media.Attributes); attributes != "" {
+		alt += " " + attributes
+	}
+	source := string(media.RelativeSource)
+	if strings.ContainsAny(source, " ()") {
+		source = "<" + source + ">"
+	}
 	if media.Caption != "" {
-		return fmt.Sprintf(`![%s %s](%s "%s")`, media.Alt, ctx.replicateMediaAttributesString(media.Attributes), string(media.RelativeSource), media.Caption)
+		return fmt.Sprintf(`![%s](%s "%s")`, alt, source, strings.ReplaceAll(media.Caption, `"`, `\"`))
 	}
-	return fmt.Sprintf(`![%s %s](%s)`, media.Alt, ctx.replicateMediaAttributesString(media.Attributes), string(media.RelativeSource))
+	return fmt.Sprintf(`![%s](%s)`, alt, source)
 }
 
-func (ctx *RunContext) replicateParagraph(anchor string, p Paragraph) (string, error) {
-	markdown := p.Content.Markdown()
-	if strings.TrimSpace(markdown) == "" {
-		markdown = "<p></p>"
+// footnoteReferencePattern matches references to footnotes, as generated when building.
+var footnoteReferencePattern = regexp.MustCompile(`<sup id="fnref:[^"]+"><a href="(#fn:[^"]+)" class="footnote-ref" role="doc-noteref">([^<]*)</a></sup>`)
+
+// replicateParagraph writes the paragraph as markdown. Paragraphs that can't be written as markdown without losing anything, such as attributes, are written as markdown with inline HTML, or else as raw HTML.
+func (ctx *RunContext) replicateParagraph(p Paragraph) (string, error) {
+	html := ctx.removeAbbreviations(soup.HTMLParse(string(p.Content)), string(p.Content))
+	candidates := []string{HTMLString(html).Markdown()}
+	if inner, ok := strings.CutPrefix(html, "<p>"); ok && strings.HasSuffix(inner, "</p>") {
+		// Footnote references are written as links, like the markdown conversion does, see transformFootnoteReferences
+		candidates = append(candidates, footnoteReferencePattern.ReplaceAllString(escapeMarkdownText(strings.TrimSuffix(inner, "</p>")), "[$2]($1)"))
 	}
-	var result string
-	if anchor != "" {
-		result = "{#" + anchor + "}\n" + markdown
-	} else {
-		result = markdown
+	for _, markdown := range candidates {
+		lossless, err := ctx.parsesBackTo(markdown, html)
+		if err != nil {
+			return "", fmt.Errorf("while checking that the paragraph can be written as markdown: %w", err)
+		}
+		if !lossless {
+			continue
+		}
+		markdown = ctx.transformFootnoteReferences(markdown)
+		if strings.TrimSpace(markdown) == "" {
+			markdown = "<p></p>"
+		}
+		return markdown, nil
 	}
-	return result, nil
+	return html, nil
+}
+
+// escapeMarkdownText escapes characters that have a meaning in markdown, outside of the tags of the given HTML.
+func escapeMarkdownText(html string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "`", "\\`", "~", `\~`)
+	tagPattern := regexp.MustCompile(`<[^>]*>`)
+	tags := tagPattern.FindAllString(html, -1)
+	escaped := ""
+	for i, text := range tagPattern.Split(html, -1) {
+		escaped += escaper.Replace(text)
+		if i < len(tags) {
+			escaped += tags[i]
+		}
+	}
+	return escaped
+}
+
+// parsesBackTo returns true if parsing markdown as a description file gives a single paragraph with the given HTML content.
+// References to footnotes are compared as the links they are written as before transformFootnoteReferences.
+func (ctx *RunContext) parsesBackTo(markdown string, html string) (bool, error) {
+	_, blocks, _, _, err := ctx.ParseSingleLanguageDescription(markdown)
+	if err != nil {
+		return false, err
+	}
+	if len(blocks) != 1 || blocks[0].Type != "paragraph" {
+		return false, nil
+	}
+	return string(blocks[0].Content) == footnoteReferencePattern.ReplaceAllString(html, `<a href="$1">$2</a>`), nil
+}
+
+// VerifyReplication parses a description file replicated from original, and returns an error if it does not describe the same work, or if replicating it again does not give the same description file.
+// Media files are not analyzed: only what the description file declares is compared.
+func (ctx *RunContext) VerifyReplication(original Work, description string) error {
+	replicated, err := ParseDescription(ctx, description, original.ID)
+	if err != nil {
+		return fmt.Errorf("replicated description file can't be parsed: %w", err)
+	}
+
+	originalMetadata, err := ctx.replicateMetadata(original)
+	if err != nil {
+		return fmt.Errorf("while replicating metadata: %w", err)
+	}
+	replicatedMetadata, err := ctx.replicateMetadata(replicated)
+	if err != nil {
+		return fmt.Errorf("while replicating metadata of the replicated description file: %w", err)
+	}
+	if originalMetadata != replicatedMetadata {
+		return fmt.Errorf("replicated description file has different metadata")
+	}
+
+	if !slices.Equal(sortedKeys(original.Content), sortedKeys(replicated.Content)) {
+		return fmt.Errorf("replicated description file is in %s instead of %s", strings.Join(sortedKeys(replicated.Content), ", "), strings.Join(sortedKeys(original.Content), ", "))
+	}
+	for _, language := range sortedKeys(original.Content) {
+		originalContent, replicatedContent := original.Content[language], replicated.Content[language]
+		for _, part := range []struct {
+			name                 string
+			original, replicated any
+		}{
+			{"title", originalContent.Title, replicatedContent.Title},
+			{"footnotes", originalContent.Footnotes, replicatedContent.Footnotes},
+			{"abbreviations", originalContent.Abbreviations, replicatedContent.Abbreviations},
+			{"blocks", declaredBlocks(originalContent.Blocks), declaredBlocks(replicatedContent.Blocks)},
+			{"layout", layoutByIndex(originalContent.Layout, originalContent.Blocks), layoutByIndex(replicatedContent.Layout, replicatedContent.Blocks)},
+		} {
+			originalJSON, _ := json.Marshal(part.original)
+			replicatedJSON, _ := json.Marshal(part.replicated)
+			if string(originalJSON) != string(replicatedJSON) {
+				return fmt.Errorf("replicated description file has different %s in %s: %s instead of %s", part.name, language, replicatedJSON, originalJSON)
+			}
+		}
+	}
+
+	again, err := ctx.ReplicateDescription(replicated)
+	if err != nil {
+		return fmt.Errorf("while replicating the replicated description file: %w", err)
+	}
+	if again != description {
+		return fmt.Errorf("replicating the replicated description file gives a different one")
+	}
+	return nil
+}
+
+// declaredBlocks returns blocks without what is computed when building, such as IDs, anchors, media analysis and resolved links to other works.
+func declaredBlocks(blocks []ContentBlock) []ContentBlock {
+	declared := make([]ContentBlock, 0, len(blocks))
+	for _, block := range blocks {
+		block = unresolveWorkLinks(block)
+		declared = append(declared, ContentBlock{
+			Type:      block.Type,
+			Name:      block.Name,
+			Paragraph: block.Paragraph,
+			Link:      block.Link,
+			Media: Media{
+				Alt:            block.Alt,
+				Caption:        block.Caption,
+				RelativeSource: block.RelativeSource,
+				Attributes:     block.Attributes,
+			},
+		})
+	}
+	return declared
+}
+
+// layoutByIndex replaces block IDs in layout with their index in blocks, so that layouts of blocks with different IDs can be compared.
+func layoutByIndex(layout Layout, blocks []ContentBlock) [][]int {
+	indices := make([][]int, 0, len(layout))
+	for _, row := range layout {
+		indicesRow := make([]int, 0, len(row))
+		for _, cell := range row {
+			indicesRow = append(indicesRow, slices.IndexFunc(blocks, func(block ContentBlock) bool { return block.ID == string(cell) }))
+		}
+		indices = append(indices, indicesRow)
+	}
+	return indices
 }
 
 func (html HTMLString) Markdown() string {
 	// TODO: configurable domain for translating relative to absolute URLS from ortfodb.yaml
 	converter := html2md.NewConverter("", true, nil)
+	converter.AddRules(emphasisRule("*", "em", "i"), emphasisRule("**", "strong", "b"))
 	result, err := converter.ConvertString(string(html))
 	if err != nil {
 		return html.String()
 	}
 	return result
 }
+
+// emphasisRule converts emphasis without adding spaces around it, which the default rules do to make underscores work and which changes the text. Asterisks work inside of words.
+func emphasisRule(delimiter string, tags ...string) html2md.Rule {
+	return html2md.Rule{
+		Filter: tags,
+		Replacement: func(content string, selection *goquery.Selection, options *html2md.Options) *string {
+			// Only use one delimiter when they are nested
+			if selection.Parent().Is(strings.Join(tags, ", ")) {
+				return &content
+			}
+			trimmed := strings.TrimSpace(content)
+			if trimmed == "" {
+				return &trimmed
+			}
+			// Delimiters don't work across lines
+			lines := strings.Split(trimmed, "\n")
+			for i, line := range lines {
+				if strings.TrimSpace(line) != "" {
+					lines[i] = delimiter + line + delimiter
+				}
+			}
+			result := strings.Join(lines, "\n")
+			return &result
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package ortfodb
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestReplicationRoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		// Changes the replicated description file before verifying it
+		tamper  func(replicated string) string
+		wantErr bool
+	}{
+		{
+			name: "metadata and blocks",
+			description: `---
+started: 2021-05
+tags: [web, design]
+made with: [go]
+wip: true
+---
+
+# Hello
+
+A paragraph with **bold** text and a [link](https://example.com).
+
+![A picture](./pic.png "Its caption")
+
+[A link](https://example.com)
+`,
+		},
+		{
+			name: "date ranges",
+			description: `---
+started: 2019–2021
+finished: 2021-03..2021-05
+---
+
+# Dates
+`,
+		},
+		{
+			name: "languages",
+			description: `---
+tags: [web]
+---
+
+:: en
+
+# Hello
+
+Hi!
+
+:: fr
+
+# Bonjour
+
+Salut !
+`,
+		},
+		{
+			name: "footnotes and abbreviations",
+			description: `# Notes
+
+ortfo is made with Go[^1], which is not an ORM.
+
+[^1]: The programming language.
+
+*[ORM]: Object-Relational Mapping
+`,
+		},
+		{
+			name: "named blocks and layout",
+			description: `---
+layout:
+  - [intro, m1]
+  - p2
+---
+
+{#intro}
+Hello
+
+![A picture](./pic.png)
+
+Second paragraph
+`,
+		},
+		{
+			name: "media attributes",
+			description: `![A video >~](./video.mp4)
+`,
+		},
+		{
+			name: "different content",
+			description: `# Hello
+
+A paragraph.
+`,
+			tamper: func(replicated string) string {
+				return strings.Replace(replicated, "A paragraph.", "Another paragraph.", 1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "different metadata",
+			description: `---
+wip: true
+---
+
+# Hello
+`,
+			tamper: func(replicated string) string {
+				return strings.Replace(replicated, "wip: true", "wip: false", 1)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfiguration()
+			ctx := &RunContext{Config: &config, previousBuiltDatabase: PreviouslyBuiltDatabase{mu: &sync.Mutex{}, Database: Database{}}}
+			original, err := ParseDescription(ctx, tt.description, "work")
+			if err != nil {
+				t.Fatalf("ParseDescription() error = %v", err)
+			}
+			replicated, err := ctx.ReplicateDescription(original)
+			if err != nil {
+				t.Fatalf("ReplicateDescription() error = %v", err)
+			}
+			if tt.tamper != nil {
+				replicated = tt.tamper(replicated)
+			}
+			if err := ctx.VerifyReplication(original, replicated); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyReplication() error = %v, wantErr %v, replicated description file:\n%s", err, tt.wantErr, replicated)
+			}
+		})
+	}
+}
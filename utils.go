@@ -12,6 +12,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	ll "github.com/gwennlbh/label-logger-go"
@@ -143,6 +144,13 @@ func mapKeys[T any](m map[string]T) []string {
 	return keys
 }
 
+// sortedKeys returns the map's keys, sorted alphabetically.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := mapKeys(m)
+	sort.Strings(keys)
+	return keys
+}
+
 func mapValues[T any](m map[string]T) []T {
 	values := make([]T, 0)
 	for _, v := range m {
@@ -301,3 +309,66 @@ func hashFile(filename string) (string, error) {
 	hash := md5.Sum(content)
 	return base64.StdEncoding.EncodeToString(hash[:]), nil
 }
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// similarity returns the Sørensen–Dice coefficient of the character bigrams of a and b: 1 for identical strings, 0 for strings that have no bigram in common.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	bigrams := func(s string) map[string]int {
+		runes := []rune(strings.ToLower(s))
+		result := make(map[string]int)
+		for i := 0; i < len(runes)-1; i++ {
+			result[string(runes[i:i+2])]++
+		}
+		return result
+	}
+	bigramsA, bigramsB := bigrams(a), bigrams(b)
+	total := 0
+	for _, count := range bigramsA {
+		total += count
+	}
+	for _, count := range bigramsB {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	common := 0
+	for bigram, count := range bigramsA {
+		common += min(count, bigramsB[bigram])
+	}
+	return float64(2*common) / float64(total)
+}
+
+// firstNonEmpty returns the first of the given strings that is not empty, or an empty string if they all are.
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}